@@ -0,0 +1,87 @@
+// Package authn は system-service / aws-service の認証（呼び出し元 subject の特定）を行う。
+//
+// 通常は JWT Bearer トークンを検証し、開発時のみ X-User-ID ヘッダーをそのまま信頼するモードを使える。
+package authn
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"authz"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gin.Context に保存するキー
+const subjectKey = "authn.subject"
+
+// ErrUnauthenticated は認証情報が無い、または検証に失敗した場合に返す
+var ErrUnauthenticated = errors.New("authn: unauthenticated")
+
+// Authenticator はリクエストから認証済みの subject を取り出す
+type Authenticator interface {
+	Authenticate(r *http.Request) (string, error)
+}
+
+// HeaderAuthenticator は指定ヘッダーの値をそのまま subject とする開発用の Authenticator。
+// 値は検証されないため、ネットワーク外に公開する環境では使用しないこと
+type HeaderAuthenticator struct {
+	Header string
+}
+
+func (a HeaderAuthenticator) Authenticate(r *http.Request) (string, error) {
+	subject := r.Header.Get(a.Header)
+	if subject == "" {
+		return "", ErrUnauthenticated
+	}
+	return subject, nil
+}
+
+// Middleware は認証を行い、成功時は subject を gin.Context に保存するミドルウェア。
+// 認証できない場合は 401 を返して中断する
+func Middleware(a Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subject, err := a.Authenticate(c.Request)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="api"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, authz.ErrorResponse{
+				Error: "認証に失敗しました: " + err.Error(),
+				Code:  "unauthenticated",
+			})
+			return
+		}
+		c.Set(subjectKey, subject)
+		c.Next()
+	}
+}
+
+// SubjectFrom は Middleware が保存した subject を返す。authz.Guard の SubjectFunc として使う
+func SubjectFrom(c *gin.Context) string {
+	return c.GetString(subjectKey)
+}
+
+// FromEnv は環境変数から Authenticator を構築する。
+// AUTH_DEV_HEADER_MODE=true の場合のみ X-User-ID ヘッダーを検証せずに信頼する（ローカル開発用）
+func FromEnv() (Authenticator, error) {
+	if os.Getenv("AUTH_DEV_HEADER_MODE") == "true" {
+		log.Println("警告: AUTH_DEV_HEADER_MODE=true のため X-User-ID ヘッダーを検証せずに使用します（開発用）")
+		return HeaderAuthenticator{Header: "X-User-ID"}, nil
+	}
+
+	jwksPath := os.Getenv("AUTH_JWKS_FILE")
+	if jwksPath == "" {
+		return nil, fmt.Errorf("AUTH_JWKS_FILE is required (set AUTH_DEV_HEADER_MODE=true for local development)")
+	}
+	keys, err := LoadKeySet(jwksPath)
+	if err != nil {
+		return nil, err
+	}
+	return NewJWTAuthenticator(keys, JWTOptions{
+		Issuer:       os.Getenv("AUTH_JWT_ISSUER"),
+		Audience:     os.Getenv("AUTH_JWT_AUDIENCE"),
+		SubjectClaim: os.Getenv("AUTH_JWT_SUBJECT_CLAIM"),
+	}), nil
+}
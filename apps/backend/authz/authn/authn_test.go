@@ -0,0 +1,89 @@
+package authn
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unsetEnv はテストの間だけ環境変数を未設定にする
+func unsetEnv(t *testing.T, name string) {
+	t.Helper()
+	t.Setenv(name, "")
+	os.Unsetenv(name)
+}
+
+func TestFromEnvRequiresJWKSWithoutDevMode(t *testing.T) {
+	unsetEnv(t, "AUTH_DEV_HEADER_MODE")
+	unsetEnv(t, "AUTH_JWKS_FILE")
+
+	if _, err := FromEnv(); err == nil {
+		t.Fatal("FromEnv succeeded without AUTH_JWKS_FILE")
+	}
+}
+
+// TestMiddlewareRequiresBearerToken は AUTH_DEV_HEADER_MODE が未設定の場合、
+// X-User-ID ヘッダーだけのリクエストを 401 で拒否し、Bearer トークンの subject のみを使うことを確認する
+func TestMiddlewareRequiresBearerToken(t *testing.T) {
+	keys := newTestKeys(t)
+	unsetEnv(t, "AUTH_DEV_HEADER_MODE")
+	t.Setenv("AUTH_JWKS_FILE", keys.jwksPath)
+	t.Setenv("AUTH_JWT_ISSUER", testIssuer)
+	t.Setenv("AUTH_JWT_AUDIENCE", testAudience)
+
+	authenticator, err := FromEnv()
+	if err != nil {
+		t.Fatalf("FromEnv: %v", err)
+	}
+	jwtAuthenticator, ok := authenticator.(*JWTAuthenticator)
+	if !ok {
+		t.Fatalf("FromEnv returned %T, want *JWTAuthenticator", authenticator)
+	}
+	jwtAuthenticator.now = func() time.Time { return testNow }
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/me", Middleware(authenticator), func(c *gin.Context) {
+		c.String(http.StatusOK, SubjectFrom(c))
+	})
+
+	token := makeToken(t, map[string]interface{}{"alg": algRS256, "kid": testRSAKid}, validClaims(), signRS256(keys.private))
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+		body    string
+	}{
+		{name: "no credentials", status: http.StatusUnauthorized},
+		{name: "X-User-ID only", headers: map[string]string{"X-User-ID": "admin"}, status: http.StatusUnauthorized},
+		{name: "empty bearer", headers: map[string]string{"Authorization": "Bearer ", "X-User-ID": "admin"}, status: http.StatusUnauthorized},
+		{name: "basic auth", headers: map[string]string{"Authorization": "Basic YWRtaW46YWRtaW4="}, status: http.StatusUnauthorized},
+		{name: "invalid bearer", headers: map[string]string{"Authorization": "Bearer not-a-jwt"}, status: http.StatusUnauthorized},
+		{name: "valid bearer", headers: map[string]string{"Authorization": "Bearer " + token, "X-User-ID": "admin"}, status: http.StatusOK, body: "alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Fatal("WWW-Authenticate header is missing")
+			}
+			if tt.body != "" && rec.Body.String() != tt.body {
+				t.Fatalf("subject = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
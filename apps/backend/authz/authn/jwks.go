@@ -0,0 +1,119 @@
+package authn
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// jwk は JWKS の1鍵分（HS256 用の oct 鍵と RS256 用の RSA 公開鍵に対応）
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	K   string `json:"k"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// RS256 の鍵として受け付ける最小の鍵長
+const minRSAKeyBits = 2048
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// verificationKey は署名検証に使う鍵
+type verificationKey struct {
+	kid    string
+	alg    string
+	secret []byte
+	public *rsa.PublicKey
+}
+
+// KeySet はローカルの JWKS ファイルから読み込んだ検証鍵の集合
+type KeySet struct {
+	keys []verificationKey
+}
+
+// LoadKeySet は path の JWKS ファイルを読み込む
+func LoadKeySet(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+
+	var set jwks
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS file: %w", err)
+	}
+
+	ks := &KeySet{}
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := parseJWK(k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key at index %d: %w", i, err)
+		}
+		ks.keys = append(ks.keys, key)
+	}
+	if len(ks.keys) == 0 {
+		return nil, fmt.Errorf("JWKS file %s contains no signing keys", path)
+	}
+	return ks, nil
+}
+
+func parseJWK(k jwk) (verificationKey, error) {
+	switch k.Kty {
+	case "oct":
+		if k.Alg != "" && k.Alg != algHS256 {
+			return verificationKey{}, fmt.Errorf("unsupported alg for oct key: %s", k.Alg)
+		}
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil || len(secret) == 0 {
+			return verificationKey{}, fmt.Errorf("invalid oct key value")
+		}
+		return verificationKey{kid: k.Kid, alg: algHS256, secret: secret}, nil
+	case "RSA":
+		if k.Alg != "" && k.Alg != algRS256 {
+			return verificationKey{}, fmt.Errorf("unsupported alg for RSA key: %s", k.Alg)
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil || len(n) == 0 {
+			return verificationKey{}, fmt.Errorf("invalid RSA modulus")
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 {
+			return verificationKey{}, fmt.Errorf("invalid RSA exponent")
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return verificationKey{}, fmt.Errorf("invalid RSA exponent")
+		}
+		public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+		if public.N.BitLen() < minRSAKeyBits {
+			return verificationKey{}, fmt.Errorf("RSA key must be at least %d bits", minRSAKeyBits)
+		}
+		return verificationKey{kid: k.Kid, alg: algRS256, public: public}, nil
+	default:
+		return verificationKey{}, fmt.Errorf("unsupported key type: %s", k.Kty)
+	}
+}
+
+// candidates は alg と kid が一致する検証鍵を返す。
+// kid を省略したトークンは kid を持たない鍵でのみ検証する（kid 付きの鍵を総当たりしない）
+func (ks *KeySet) candidates(alg, kid string) []verificationKey {
+	var keys []verificationKey
+	for _, k := range ks.keys {
+		if k.alg == alg && k.kid == kid {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,166 @@
+package authn
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 対応する署名アルゴリズム
+const (
+	algHS256 = "HS256"
+	algRS256 = "RS256"
+)
+
+// 有効期限の判定で許容する時計のずれ
+const clockSkew = 30 * time.Second
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// JWTOptions は JWTAuthenticator の検証条件
+type JWTOptions struct {
+	Issuer       string // 空の場合は iss を検証しない
+	Audience     string // 空の場合は aud を検証しない
+	SubjectClaim string // subject として使うクレーム名（デフォルト "sub"）
+}
+
+// JWTAuthenticator は Authorization: Bearer の JWT を JWKS の鍵で検証する Authenticator
+type JWTAuthenticator struct {
+	keys *KeySet
+	opts JWTOptions
+	now  func() time.Time
+}
+
+// NewJWTAuthenticator は keys で署名を検証する JWTAuthenticator を生成する
+func NewJWTAuthenticator(keys *KeySet, opts JWTOptions) *JWTAuthenticator {
+	if opts.SubjectClaim == "" {
+		opts.SubjectClaim = "sub"
+	}
+	return &JWTAuthenticator{keys: keys, opts: opts, now: time.Now}
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (string, error) {
+	authHeader := r.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(authHeader, "Bearer ")
+	if !ok || token == "" {
+		return "", fmt.Errorf("%w: bearer token is required", ErrUnauthenticated)
+	}
+	return a.Verify(token)
+}
+
+// Verify はトークンの署名とクレームを検証し、subject を返す
+func (a *JWTAuthenticator) Verify(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("%w: malformed token", ErrUnauthenticated)
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return "", fmt.Errorf("%w: invalid header", ErrUnauthenticated)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid signature encoding", ErrUnauthenticated)
+	}
+
+	// alg は鍵の種類と一致するものだけを受け付ける（alg=none や HS/RS の取り違えを防ぐ）
+	if header.Alg != algHS256 && header.Alg != algRS256 {
+		return "", fmt.Errorf("%w: unsupported alg %q", ErrUnauthenticated, header.Alg)
+	}
+	if !a.verifySignature(header, parts[0]+"."+parts[1], signature) {
+		return "", fmt.Errorf("%w: signature verification failed", ErrUnauthenticated)
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", fmt.Errorf("%w: invalid claims", ErrUnauthenticated)
+	}
+	if err := a.validateClaims(claims); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+
+	subject, _ := claims[a.opts.SubjectClaim].(string)
+	if subject == "" {
+		return "", fmt.Errorf("%w: claim %q is missing", ErrUnauthenticated, a.opts.SubjectClaim)
+	}
+	return subject, nil
+}
+
+func (a *JWTAuthenticator) verifySignature(header jwtHeader, signingInput string, signature []byte) bool {
+	digest := sha256.Sum256([]byte(signingInput))
+	for _, key := range a.keys.candidates(header.Alg, header.Kid) {
+		switch key.alg {
+		case algHS256:
+			mac := hmac.New(sha256.New, key.secret)
+			mac.Write([]byte(signingInput))
+			if hmac.Equal(mac.Sum(nil), signature) {
+				return true
+			}
+		case algRS256:
+			if rsa.VerifyPKCS1v15(key.public, crypto.SHA256, digest[:], signature) == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (a *JWTAuthenticator) validateClaims(claims map[string]interface{}) error {
+	now := a.now()
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("exp claim is required")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(clockSkew)) {
+		return fmt.Errorf("token is expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(clockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return fmt.Errorf("token is not valid yet")
+	}
+
+	if a.opts.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != a.opts.Issuer {
+			return fmt.Errorf("unexpected issuer")
+		}
+	}
+	if a.opts.Audience != "" && !hasAudience(claims["aud"], a.opts.Audience) {
+		return fmt.Errorf("unexpected audience")
+	}
+	return nil
+}
+
+// hasAudience は aud クレーム（文字列または配列）に audience が含まれるかを返す
+func hasAudience(aud interface{}, audience string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == audience
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,305 @@
+package authn
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://issuer.example"
+	testAudience = "system-service"
+	testRSAKid   = "rsa-1"
+	testHMACKid  = "hmac-1"
+)
+
+var (
+	testNow    = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	testSecret = []byte("0123456789abcdef0123456789abcdef")
+)
+
+// testKeys はテスト用の RSA 鍵と、RSA 鍵・HMAC 鍵を1つずつ含む JWKS ファイル
+type testKeys struct {
+	private  *rsa.PrivateKey
+	jwksPath string
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	path := writeJWKS(t, []jwk{rsaJWK(testRSAKid, &private.PublicKey), hmacJWK(testHMACKid, testSecret)})
+	return testKeys{private: private, jwksPath: path}
+}
+
+func (k testKeys) authenticator(t *testing.T) *JWTAuthenticator {
+	t.Helper()
+	keys, err := LoadKeySet(k.jwksPath)
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+	a := NewJWTAuthenticator(keys, JWTOptions{Issuer: testIssuer, Audience: testAudience})
+	a.now = func() time.Time { return testNow }
+	return a
+}
+
+func rsaJWK(kid string, public *rsa.PublicKey) jwk {
+	return jwk{
+		Kty: "RSA",
+		Kid: kid,
+		Alg: algRS256,
+		Use: "sig",
+		N:   b64(public.N.Bytes()),
+		E:   b64(big.NewInt(int64(public.E)).Bytes()),
+	}
+}
+
+func hmacJWK(kid string, secret []byte) jwk {
+	return jwk{Kty: "oct", Kid: kid, Alg: algHS256, K: b64(secret)}
+}
+
+func writeJWKS(t *testing.T, keys []jwk) string {
+	t.Helper()
+	data, err := json.Marshal(jwks{Keys: keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b64(data)
+}
+
+// signer は署名対象の文字列から署名を作る
+type signer func(t *testing.T, signingInput string) []byte
+
+func signRS256(private *rsa.PrivateKey) signer {
+	return func(t *testing.T, signingInput string) []byte {
+		digest := sha256.Sum256([]byte(signingInput))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, private, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signature
+	}
+}
+
+func signHS256(secret []byte) signer {
+	return func(t *testing.T, signingInput string) []byte {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signingInput))
+		return mac.Sum(nil)
+	}
+}
+
+func noSignature(*testing.T, string) []byte { return nil }
+
+func makeToken(t *testing.T, header, claims map[string]interface{}, sign signer) string {
+	t.Helper()
+	signingInput := encodeSegment(t, header) + "." + encodeSegment(t, claims)
+	return signingInput + "." + b64(sign(t, signingInput))
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub": "alice",
+		"iss": testIssuer,
+		"aud": testAudience,
+		"exp": testNow.Add(time.Hour).Unix(),
+		"nbf": testNow.Add(-time.Minute).Unix(),
+	}
+}
+
+// with は validClaims の一部を上書き（値が nil の場合は削除）したクレーム
+func with(overrides map[string]interface{}) map[string]interface{} {
+	claims := validClaims()
+	for k, v := range overrides {
+		if v == nil {
+			delete(claims, k)
+		} else {
+			claims[k] = v
+		}
+	}
+	return claims
+}
+
+func TestVerify(t *testing.T) {
+	keys := newTestKeys(t)
+	a := keys.authenticator(t)
+
+	rsHeader := map[string]interface{}{"alg": algRS256, "kid": testRSAKid, "typ": "JWT"}
+	hsHeader := map[string]interface{}{"alg": algHS256, "kid": testHMACKid, "typ": "JWT"}
+	rs := signRS256(keys.private)
+	hs := signHS256(testSecret)
+
+	publicDER, err := x509.MarshalPKIXPublicKey(&keys.private.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := makeToken(t, rsHeader, validClaims(), rs)
+	validParts := strings.Split(valid, ".")
+
+	tests := []struct {
+		name    string
+		token   string
+		subject string // 空の場合は拒否されること
+	}{
+		{name: "valid RS256", token: valid, subject: "alice"},
+		{name: "valid HS256", token: makeToken(t, hsHeader, validClaims(), hs), subject: "alice"},
+		{name: "aud as array", token: makeToken(t, rsHeader, with(map[string]interface{}{"aud": []string{"other", testAudience}}), rs), subject: "alice"},
+		{name: "expired within clock skew", token: makeToken(t, rsHeader, with(map[string]interface{}{"exp": testNow.Add(-clockSkew / 2).Unix()}), rs), subject: "alice"},
+
+		{name: "alg none", token: makeToken(t, map[string]interface{}{"alg": "none", "typ": "JWT"}, validClaims(), noSignature)},
+		{name: "alg none with kid", token: makeToken(t, map[string]interface{}{"alg": "none", "kid": testRSAKid}, validClaims(), noSignature)},
+		{name: "alg None", token: makeToken(t, map[string]interface{}{"alg": "None", "kid": testRSAKid}, validClaims(), noSignature)},
+		{name: "HS256 signed with RSA public key PEM", token: makeToken(t, map[string]interface{}{"alg": algHS256, "kid": testRSAKid}, validClaims(), signHS256(publicPEM))},
+		{name: "HS256 signed with RSA public key DER", token: makeToken(t, map[string]interface{}{"alg": algHS256, "kid": testRSAKid}, validClaims(), signHS256(publicDER))},
+		{name: "HS256 signed with RSA modulus", token: makeToken(t, map[string]interface{}{"alg": algHS256, "kid": testRSAKid}, validClaims(), signHS256(keys.private.N.Bytes()))},
+		{name: "RS256 with HMAC kid", token: makeToken(t, map[string]interface{}{"alg": algRS256, "kid": testHMACKid}, validClaims(), rs)},
+		{name: "unknown kid", token: makeToken(t, map[string]interface{}{"alg": algRS256, "kid": "rsa-2"}, validClaims(), rs)},
+		{name: "missing kid", token: makeToken(t, map[string]interface{}{"alg": algRS256}, validClaims(), rs)},
+		{name: "signed by another key", token: makeToken(t, rsHeader, validClaims(), signRS256(otherKey))},
+		{name: "HS256 with wrong secret", token: makeToken(t, hsHeader, validClaims(), signHS256([]byte("wrong-secret")))},
+		{name: "tampered claims", token: validParts[0] + "." + encodeSegment(t, with(map[string]interface{}{"sub": "admin"})) + "." + validParts[2]},
+		{name: "truncated signature", token: validParts[0] + "." + validParts[1] + "." + validParts[2][:len(validParts[2])-4]},
+		{name: "empty signature", token: validParts[0] + "." + validParts[1] + "."},
+		{name: "expired", token: makeToken(t, rsHeader, with(map[string]interface{}{"exp": testNow.Add(-time.Hour).Unix()}), rs)},
+		{name: "missing exp", token: makeToken(t, rsHeader, with(map[string]interface{}{"exp": nil}), rs)},
+		{name: "nbf in the future", token: makeToken(t, rsHeader, with(map[string]interface{}{"nbf": testNow.Add(time.Hour).Unix()}), rs)},
+		{name: "wrong iss", token: makeToken(t, rsHeader, with(map[string]interface{}{"iss": "https://evil.example"}), rs)},
+		{name: "missing iss", token: makeToken(t, rsHeader, with(map[string]interface{}{"iss": nil}), rs)},
+		{name: "wrong aud", token: makeToken(t, rsHeader, with(map[string]interface{}{"aud": "aws-service"}), rs)},
+		{name: "aud array without audience", token: makeToken(t, rsHeader, with(map[string]interface{}{"aud": []string{"aws-service"}}), rs)},
+		{name: "missing sub", token: makeToken(t, rsHeader, with(map[string]interface{}{"sub": nil}), rs)},
+		{name: "empty token", token: ""},
+		{name: "two segments", token: validParts[0] + "." + validParts[1]},
+		{name: "four segments", token: valid + "." + validParts[2]},
+		{name: "header is not base64url", token: "!!!." + validParts[1] + "." + validParts[2]},
+		{name: "header is not JSON", token: b64([]byte("not json")) + "." + validParts[1] + "." + validParts[2]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject, err := a.Verify(tt.token)
+			if tt.subject == "" {
+				if err == nil {
+					t.Fatalf("token was accepted as %q", subject)
+				}
+				if !errors.Is(err, ErrUnauthenticated) {
+					t.Fatalf("error %v does not wrap ErrUnauthenticated", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("token was rejected: %v", err)
+			}
+			if subject != tt.subject {
+				t.Fatalf("subject = %q, want %q", subject, tt.subject)
+			}
+		})
+	}
+}
+
+func TestVerifyWithoutIssuerAndAudience(t *testing.T) {
+	keys := newTestKeys(t)
+	set, err := LoadKeySet(keys.jwksPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewJWTAuthenticator(set, JWTOptions{SubjectClaim: "email"})
+	a.now = func() time.Time { return testNow }
+
+	token := makeToken(t, map[string]interface{}{"alg": algRS256, "kid": testRSAKid},
+		with(map[string]interface{}{"iss": nil, "aud": nil, "email": "alice@example.com"}), signRS256(keys.private))
+	subject, err := a.Verify(token)
+	if err != nil {
+		t.Fatalf("token was rejected: %v", err)
+	}
+	if subject != "alice@example.com" {
+		t.Fatalf("subject = %q, want the email claim", subject)
+	}
+}
+
+func TestLoadKeySet(t *testing.T) {
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withExponent := func(e []byte) jwk {
+		k := rsaJWK("rsa", &valid.PublicKey)
+		k.E = b64(e)
+		return k
+	}
+
+	tests := []struct {
+		name string
+		keys []jwk
+		ok   bool
+	}{
+		{name: "RSA and oct keys", keys: []jwk{rsaJWK("rsa", &valid.PublicKey), hmacJWK("hmac", testSecret)}, ok: true},
+		{name: "encryption keys are skipped", keys: []jwk{{Kty: "RSA", Use: "enc"}, hmacJWK("hmac", testSecret)}, ok: true},
+		{name: "only encryption keys", keys: []jwk{{Kty: "RSA", Use: "enc"}}},
+		{name: "no keys", keys: nil},
+		{name: "unsupported kty", keys: []jwk{{Kty: "EC", Kid: "ec"}}},
+		{name: "oct key with RS256", keys: []jwk{{Kty: "oct", Kid: "hmac", Alg: algRS256, K: b64(testSecret)}}},
+		{name: "oct key without value", keys: []jwk{{Kty: "oct", Kid: "hmac"}}},
+		{name: "oct key not base64url", keys: []jwk{{Kty: "oct", Kid: "hmac", K: "!!!"}}},
+		{name: "RSA key with HS256", keys: []jwk{func() jwk { k := rsaJWK("rsa", &valid.PublicKey); k.Alg = algHS256; return k }()}},
+		{name: "RSA key without modulus", keys: []jwk{func() jwk { k := rsaJWK("rsa", &valid.PublicKey); k.N = ""; return k }()}},
+		{name: "RSA key shorter than 2048 bits", keys: []jwk{rsaJWK("rsa", &small.PublicKey)}},
+		{name: "RSA exponent 1", keys: []jwk{withExponent([]byte{1})}},
+		{name: "RSA exponent too large", keys: []jwk{withExponent([]byte{1, 0, 0, 0, 0, 0, 0, 0, 1})}},
+		{name: "RSA key without exponent", keys: []jwk{withExponent(nil)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadKeySet(writeJWKS(t, tt.keys))
+			if tt.ok && err != nil {
+				t.Fatalf("LoadKeySet: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("invalid JWKS was accepted")
+			}
+		})
+	}
+}
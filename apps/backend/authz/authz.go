@@ -0,0 +1,122 @@
+// Package authz は system-service / aws-service で共有する認可クライアント。
+//
+// 認可の問い合わせは (subject, resource type, resource id, permission) の
+// 共通モデルで表現し、Casbin / OPA / SpiceDB の各実装がそれぞれの語彙へ変換する。
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// リソース種別
+const (
+	ResourceSystem = "system"
+	ResourceAWS    = "aws"
+)
+
+// 権限
+const (
+	PermissionRead          = "read"
+	PermissionWrite         = "write"
+	PermissionDelete        = "delete"
+	PermissionManageMembers = "manage_members"
+)
+
+// ErrNotSupported はエンジンが該当の操作に対応していない場合に返す
+var ErrNotSupported = errors.New("authz: operation not supported by engine")
+
+// Request は認可チェック1件分の問い合わせ
+type Request struct {
+	Subject      string
+	ResourceType string
+	ResourceID   string
+	Permission   string
+}
+
+// ResourceSet は LookupResources の結果。All が true の場合は種別内の全リソースが対象
+type ResourceSet struct {
+	All bool
+	IDs []string
+}
+
+// Contains は id が集合に含まれるかを返す
+func (s ResourceSet) Contains(id string) bool {
+	if s.All {
+		return true
+	}
+	for _, v := range s.IDs {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorizer は認可エンジンの共通インターフェース
+type Authorizer interface {
+	// Name はエンジン名（ルートのプレフィックスにも使用する）
+	Name() string
+	// Check は1件の認可チェックを行う
+	Check(ctx context.Context, req Request) (bool, error)
+	// BulkCheck は複数の認可チェックを行い、reqs と同じ順序で結果を返す
+	BulkCheck(ctx context.Context, reqs []Request) ([]bool, error)
+	// LookupResources は subject が permission を持つ resourceType のリソースを返す
+	LookupResources(ctx context.Context, subject, resourceType, permission string) (ResourceSet, error)
+}
+
+// FilterAllowed は ids のうち subject が permission を持つものだけを返す。
+// LookupResources に対応していないエンジンでは BulkCheck で判定する
+func FilterAllowed(ctx context.Context, a Authorizer, subject, resourceType, permission string, ids []string) ([]string, error) {
+	set, err := a.LookupResources(ctx, subject, resourceType, permission)
+	if err == nil {
+		allowed := []string{}
+		for _, id := range ids {
+			if set.Contains(id) {
+				allowed = append(allowed, id)
+			}
+		}
+		return allowed, nil
+	}
+	if !errors.Is(err, ErrNotSupported) {
+		return nil, err
+	}
+
+	reqs := make([]Request, len(ids))
+	for i, id := range ids {
+		reqs[i] = Request{Subject: subject, ResourceType: resourceType, ResourceID: id, Permission: permission}
+	}
+	results, err := a.BulkCheck(ctx, reqs)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := []string{}
+	for i, ok := range results {
+		if ok {
+			allowed = append(allowed, ids[i])
+		}
+	}
+	return allowed, nil
+}
+
+// maxBatchSize は認可サーバへの一括問い合わせ1回あたりの最大件数（サーバ側の上限に合わせる）
+const maxBatchSize = 1000
+
+// inBatches は reqs を maxBatchSize ごとに分けて check を呼び出し、結果を reqs と同じ順序で連結する
+func inBatches(reqs []Request, check func([]Request) ([]bool, error)) ([]bool, error) {
+	results := make([]bool, 0, len(reqs))
+	for start := 0; start < len(reqs); start += maxBatchSize {
+		end := min(start+maxBatchSize, len(reqs))
+		batch, err := check(reqs[start:end])
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("authz: expected %d results, got %d", end-start, len(batch))
+		}
+		results = append(results, batch...)
+	}
+	return results, nil
+}
@@ -0,0 +1,209 @@
+package authz
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheOptions は DecisionCache の設定
+type CacheOptions struct {
+	TTL         time.Duration // 許可の判定結果を保持する期間
+	NegativeTTL time.Duration // 拒否の判定結果を保持する期間（0 の場合は拒否をキャッシュしない）
+	MaxEntries  int           // 保持する最大件数。超えた場合は最も古く使われたものから削除する
+}
+
+// CacheStats はキャッシュのヒット/ミス数
+type CacheStats struct {
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Entries int    `json:"entries"`
+}
+
+type cacheKey struct {
+	engine string
+	Request
+}
+
+type cacheEntry struct {
+	key       cacheKey
+	allowed   bool
+	expiresAt time.Time
+}
+
+// DecisionCache は認可判定結果のプロセス内キャッシュ。
+// キーは (エンジン, subject, リソース, 権限) で、複数エンジンの Authorizer で共有できる
+type DecisionCache struct {
+	opts CacheOptions
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[cacheKey]*list.Element
+	lru     *list.List
+	// generation は無効化のたびに増える。問い合わせ中に無効化された判定結果は保存しない
+	generation uint64
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// NewDecisionCache は opts の設定で DecisionCache を生成する
+func NewDecisionCache(opts CacheOptions) *DecisionCache {
+	return &DecisionCache{
+		opts:    opts,
+		now:     time.Now,
+		entries: map[cacheKey]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+// Wrap は a の Check / BulkCheck の結果をキャッシュする Authorizer を返す
+func (c *DecisionCache) Wrap(a Authorizer) Authorizer {
+	return &cachedAuthorizer{Authorizer: a, cache: c}
+}
+
+// InvalidateResource はリソースに関するキャッシュを削除する（メンバーの追加・削除・ロール変更時）
+func (c *DecisionCache) InvalidateResource(resourceType, resourceID string) {
+	c.invalidate(func(k cacheKey) bool { return k.ResourceType == resourceType && k.ResourceID == resourceID })
+}
+
+// Purge はすべてのキャッシュを削除する
+func (c *DecisionCache) Purge() {
+	c.invalidate(func(cacheKey) bool { return true })
+}
+
+// Stats は現在のヒット/ミス数と件数を返す
+func (c *DecisionCache) Stats() CacheStats {
+	c.mu.Lock()
+	entries := c.lru.Len()
+	c.mu.Unlock()
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load(), Entries: entries}
+}
+
+func (c *DecisionCache) invalidate(match func(cacheKey) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for key, elem := range c.entries {
+		if match(key) {
+			c.lru.Remove(elem)
+			delete(c.entries, key)
+		}
+	}
+}
+
+// currentGeneration はエンジンに問い合わせる前に取得し、結果を set するときに渡す
+func (c *DecisionCache) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+func (c *DecisionCache) get(key cacheKey) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		c.misses.Add(1)
+		return false, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if c.now().After(entry.expiresAt) {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+		c.misses.Add(1)
+		return false, false
+	}
+	c.lru.MoveToFront(elem)
+	c.hits.Add(1)
+	return entry.allowed, true
+}
+
+// set は判定結果を保存する。generation の取得後に無効化された場合は、無効化前の状態の判定かもしれないため保存しない
+func (c *DecisionCache) set(key cacheKey, allowed bool, generation uint64) {
+	ttl := c.opts.TTL
+	if !allowed {
+		ttl = c.opts.NegativeTTL
+	}
+	if ttl <= 0 || c.opts.MaxEntries <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation {
+		return
+	}
+
+	entry := &cacheEntry{key: key, allowed: allowed, expiresAt: c.now().Add(ttl)}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+
+	for c.lru.Len() > c.opts.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// cachedAuthorizer は DecisionCache を通して Authorizer を呼び出す。
+// LookupResources は結果が大きくなりうるためキャッシュしない
+type cachedAuthorizer struct {
+	Authorizer
+	cache *DecisionCache
+}
+
+func (a *cachedAuthorizer) key(req Request) cacheKey {
+	return cacheKey{engine: a.Name(), Request: req}
+}
+
+func (a *cachedAuthorizer) Check(ctx context.Context, req Request) (bool, error) {
+	key := a.key(req)
+	generation := a.cache.currentGeneration()
+	if allowed, ok := a.cache.get(key); ok {
+		return allowed, nil
+	}
+
+	allowed, err := a.Authorizer.Check(ctx, req)
+	if err != nil {
+		return false, err
+	}
+	a.cache.set(key, allowed, generation)
+	return allowed, nil
+}
+
+// BulkCheck はキャッシュにない問い合わせだけをまとめてエンジンに送る
+func (a *cachedAuthorizer) BulkCheck(ctx context.Context, reqs []Request) ([]bool, error) {
+	results := make([]bool, len(reqs))
+	generation := a.cache.currentGeneration()
+	var missIdx []int
+	var missReqs []Request
+	for i, req := range reqs {
+		if allowed, ok := a.cache.get(a.key(req)); ok {
+			results[i] = allowed
+			continue
+		}
+		missIdx = append(missIdx, i)
+		missReqs = append(missReqs, req)
+	}
+	if len(missReqs) == 0 {
+		return results, nil
+	}
+
+	fetched, err := a.Authorizer.BulkCheck(ctx, missReqs)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range missIdx {
+		results[i] = fetched[j]
+		a.cache.set(a.key(missReqs[j]), fetched[j], generation)
+	}
+	return results, nil
+}
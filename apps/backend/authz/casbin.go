@@ -0,0 +1,190 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Casbin 認可用の構造体
+type casbinAuthRequest struct {
+	Subject string `json:"subject"`
+	Domain  string `json:"domain"`
+	Object  string `json:"object"`
+	Action  string `json:"action"`
+}
+
+type casbinAuthResponse struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type casbinRoleRequest struct {
+	User   string `json:"user"`
+	Role   string `json:"role"`
+	Domain string `json:"domain"`
+}
+
+type casbinBatchAuthRequest struct {
+	Requests []casbinAuthRequest `json:"requests"`
+}
+
+type casbinBatchAuthResponse struct {
+	Results []casbinAuthResponse `json:"results"`
+}
+
+// Casbinのポリシーはパス + HTTPメソッドで記述されているため、権限をメソッドに変換する
+var casbinActions = map[string]string{
+	PermissionRead:          http.MethodGet,
+	PermissionWrite:         http.MethodPut,
+	PermissionDelete:        http.MethodDelete,
+	PermissionManageMembers: http.MethodPost,
+}
+
+// CasbinAuthorizer は Casbin 認可サーバを使う Authorizer
+type CasbinAuthorizer struct {
+	baseURL string
+	authKey string
+	client  *http.Client
+}
+
+// NewCasbinAuthorizer は baseURL の Casbin 認可サーバを authKey（preshared key）で使う Authorizer を生成する
+func NewCasbinAuthorizer(baseURL, authKey string, opts ...Option) *CasbinAuthorizer {
+	o := applyOptions(opts)
+	return &CasbinAuthorizer{baseURL: baseURL, authKey: authKey, client: o.client}
+}
+
+func (a *CasbinAuthorizer) Name() string { return "casbin" }
+
+// casbinDomain はロールを割り当てるドメイン（例: system:system1）を返す
+func casbinDomain(resourceType, resourceID string) string {
+	return resourceType + ":" + resourceID
+}
+
+// translate は共通モデルを Casbin の (domain, object, action) に変換する
+func (a *CasbinAuthorizer) translate(req Request) (casbinAuthRequest, error) {
+	action, ok := casbinActions[req.Permission]
+	if !ok {
+		return casbinAuthRequest{}, fmt.Errorf("unknown permission for casbin: %s", req.Permission)
+	}
+	return casbinAuthRequest{
+		Subject: req.Subject,
+		Domain:  casbinDomain(req.ResourceType, req.ResourceID),
+		Object:  "/" + req.ResourceType + "/" + req.ResourceID,
+		Action:  action,
+	}, nil
+}
+
+func (a *CasbinAuthorizer) Check(ctx context.Context, req Request) (bool, error) {
+	authReq, err := a.translate(req)
+	if err != nil {
+		return false, err
+	}
+
+	var authResp casbinAuthResponse
+	err = postJSON(ctx, a.client, a.baseURL+"/authorize", bearer(a.authKey), authReq, &authResp)
+	if err != nil {
+		return false, fmt.Errorf("casbin authorization failed: %w", err)
+	}
+	return authResp.Allowed, nil
+}
+
+// BulkCheck は /authorize/batch で複数の認可チェックを1回の呼び出しで行う
+func (a *CasbinAuthorizer) BulkCheck(ctx context.Context, reqs []Request) ([]bool, error) {
+	return inBatches(reqs, func(batch []Request) ([]bool, error) {
+		batchReq := casbinBatchAuthRequest{Requests: make([]casbinAuthRequest, len(batch))}
+		for i, req := range batch {
+			authReq, err := a.translate(req)
+			if err != nil {
+				return nil, err
+			}
+			batchReq.Requests[i] = authReq
+		}
+
+		var batchResp casbinBatchAuthResponse
+		if err := postJSON(ctx, a.client, a.baseURL+"/authorize/batch", bearer(a.authKey), batchReq, &batchResp); err != nil {
+			return nil, fmt.Errorf("casbin batch authorization failed: %w", err)
+		}
+
+		results := make([]bool, len(batchResp.Results))
+		for i, r := range batchResp.Results {
+			results[i] = r.Allowed
+		}
+		return results, nil
+	})
+}
+
+func (a *CasbinAuthorizer) LookupResources(ctx context.Context, subject, resourceType, permission string) (ResourceSet, error) {
+	return ResourceSet{}, ErrNotSupported
+}
+
+// ApplyMembership は g（ユーザー, ロール, ドメイン）を付け替える。
+// ロールの権限は policy.csv でリソース種別ごとに定義済みのため、p 行は変更しない
+func (a *CasbinAuthorizer) ApplyMembership(ctx context.Context, change MembershipChange) error {
+	if change.NewRole != "" && !isMembershipRole(change.ResourceType, change.NewRole) {
+		return fmt.Errorf("unknown role for casbin: %s/%s", change.ResourceType, change.NewRole)
+	}
+
+	domain := casbinDomain(change.ResourceType, change.ResourceID)
+	var resp map[string]interface{}
+	if change.OldRole != "" {
+		err := postJSON(ctx, a.client, a.baseURL+"/remove-role", bearer(a.authKey), casbinRoleRequest{
+			User:   change.Subject,
+			Role:   change.OldRole,
+			Domain: domain,
+		}, &resp)
+		if err != nil {
+			return fmt.Errorf("casbin remove role failed: %w", err)
+		}
+	}
+	if change.NewRole == "" {
+		return nil
+	}
+
+	err := postJSON(ctx, a.client, a.baseURL+"/add-role", bearer(a.authKey), casbinRoleRequest{
+		User:   change.Subject,
+		Role:   change.NewRole,
+		Domain: domain,
+	}, &resp)
+	if err != nil {
+		return fmt.Errorf("casbin add role failed: %w", err)
+	}
+	return nil
+}
+
+// parseCasbinDomain は casbinDomain の逆変換。
+// リソースのドメインでない場合（global や、ロールの継承に使う system:* などのパターン）は ok=false
+func parseCasbinDomain(domain string) (resourceType, resourceID string, ok bool) {
+	resourceType, resourceID, found := strings.Cut(domain, ":")
+	if !found || strings.Contains(resourceID, "*") {
+		return "", "", false
+	}
+	if _, known := RolePermissions[resourceType]; !known {
+		return "", "", false
+	}
+	return resourceType, resourceID, true
+}
+
+// ListMemberships は g（ユーザー, ロール, ドメイン）からロール割り当てを読み出す
+func (a *CasbinAuthorizer) ListMemberships(ctx context.Context) ([]Membership, error) {
+	var resp struct {
+		Groups [][]string `json:"groups"`
+	}
+	if err := doJSON(ctx, a.client, http.MethodGet, a.baseURL+"/groups", bearer(a.authKey), nil, &resp); err != nil {
+		return nil, fmt.Errorf("casbin list groups failed: %w", err)
+	}
+
+	var memberships []Membership
+	for _, group := range resp.Groups {
+		if len(group) < 3 {
+			continue
+		}
+		resourceType, resourceID, ok := parseCasbinDomain(group[2])
+		if !ok || !isMembershipRole(resourceType, group[1]) {
+			continue
+		}
+		memberships = append(memberships, Membership{ResourceType: resourceType, ResourceID: resourceID, Subject: group[0], Role: group[1]})
+	}
+	return memberships, nil
+}
@@ -0,0 +1,124 @@
+package authz
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// 各認可サーバの URL と SpiceDB の認証キー（system-service / aws-service / reconcile で共通の環境変数）
+var (
+	casbinServiceURL  = getenv("CASBIN_SERVICE_URL", "http://casbin-server:8080")
+	opaServiceURL     = getenv("OPA_SERVICE_URL", "http://opa-server:8081")
+	spiceDBServiceURL = getenv("SPICEDB_SERVICE_URL", "http://spicedb-server:8082")
+	spiceDBAuthKey    = getenv("SPICEDB_AUTH_KEY", "spicedb-secret-key")
+)
+
+func getenv(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// Casbin / OPA の認証キー（preshared key）を環境変数から取得する。
+// リポジトリから読める既定値は使わず、未設定の場合は失敗する
+func requireAuthKey(name string) (string, error) {
+	key := os.Getenv(name)
+	if key == "" {
+		return "", fmt.Errorf("%s is required", name)
+	}
+	return key, nil
+}
+
+// ClientOptionsFromEnv は認可サーバへの接続オプションを環境変数から構築する。
+// AUTHZ_TLS_CERT_FILE / AUTHZ_TLS_KEY_FILE が設定されている場合はクライアント証明書（mTLS）で接続する
+func ClientOptionsFromEnv() ([]Option, error) {
+	certFile := os.Getenv("AUTHZ_TLS_CERT_FILE")
+	if certFile == "" {
+		return nil, nil
+	}
+	client, err := NewMTLSClient(certFile, os.Getenv("AUTHZ_TLS_KEY_FILE"), os.Getenv("AUTHZ_TLS_CA_FILE"))
+	if err != nil {
+		return nil, err
+	}
+	return []Option{WithHTTPClient(client)}, nil
+}
+
+// CasbinAuthorizerFromEnv は CASBIN_SERVICE_URL / CASBIN_AUTH_KEY の Casbin 認可サーバを使う Authorizer を生成する
+func CasbinAuthorizerFromEnv(opts ...Option) (*CasbinAuthorizer, error) {
+	key, err := requireAuthKey("CASBIN_AUTH_KEY")
+	if err != nil {
+		return nil, err
+	}
+	return NewCasbinAuthorizer(casbinServiceURL, key, opts...), nil
+}
+
+// OPAAuthorizerFromEnv は OPA_SERVICE_URL / OPA_AUTH_KEY の OPA 認可サーバを使う Authorizer を生成する
+func OPAAuthorizerFromEnv(opts ...Option) (*OPAAuthorizer, error) {
+	key, err := requireAuthKey("OPA_AUTH_KEY")
+	if err != nil {
+		return nil, err
+	}
+	return NewOPAAuthorizer(opaServiceURL, key, opts...), nil
+}
+
+// SpiceDBAuthorizerFromEnv は SPICEDB_SERVICE_URL / SPICEDB_AUTH_KEY の SpiceDB を使う Authorizer を生成する
+func SpiceDBAuthorizerFromEnv() *SpiceDBAuthorizer {
+	return NewSpiceDBAuthorizer(spiceDBServiceURL, spiceDBAuthKey)
+}
+
+// AuthorizersFromEnv は利用する認可エンジンの一覧と、outbox のメンバー変更を書き込む先のエンジンを構築する。
+// 判定は cache を通す。各エンジンは /api/<Name()> にマウントされる
+func AuthorizersFromEnv(cache *DecisionCache) ([]Authorizer, []MembershipWriter, error) {
+	opts, err := ClientOptionsFromEnv()
+	if err != nil {
+		return nil, nil, err
+	}
+	casbin, err := CasbinAuthorizerFromEnv(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	opa, err := OPAAuthorizerFromEnv(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	spicedb := SpiceDBAuthorizerFromEnv()
+
+	authorizers := []Authorizer{cache.Wrap(casbin), cache.Wrap(opa), cache.Wrap(spicedb)}
+	return authorizers, []MembershipWriter{casbin, opa, spicedb}, nil
+}
+
+// DecisionCacheFromEnv は認可判定のキャッシュを環境変数（AUTHZ_CACHE_*）から構築する。AUTHZ_CACHE_TTL=0 の場合はキャッシュしない
+func DecisionCacheFromEnv() (*DecisionCache, error) {
+	opts := CacheOptions{
+		TTL:         30 * time.Second,
+		NegativeTTL: 5 * time.Second,
+		MaxEntries:  10000,
+	}
+
+	if v := os.Getenv("AUTHZ_CACHE_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AUTHZ_CACHE_TTL: %w", err)
+		}
+		opts.TTL = ttl
+	}
+	if v := os.Getenv("AUTHZ_CACHE_NEGATIVE_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AUTHZ_CACHE_NEGATIVE_TTL: %w", err)
+		}
+		opts.NegativeTTL = ttl
+	}
+	if v := os.Getenv("AUTHZ_CACHE_MAX_ENTRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AUTHZ_CACHE_MAX_ENTRIES: %w", err)
+		}
+		opts.MaxEntries = n
+	}
+
+	return NewDecisionCache(opts), nil
+}
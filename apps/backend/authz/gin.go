@@ -0,0 +1,131 @@
+package authz
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gin.Context に保存するキー
+const (
+	subjectKey  = "authz.subject"
+	decisionKey = "authz.decision"
+)
+
+// Decision は RequirePermission が行った認可判定の結果
+type Decision struct {
+	Request Request
+	Allowed bool
+}
+
+// ErrorResponse は認可ミドルウェアが返すエラーレスポンス
+type ErrorResponse struct {
+	Error      string `json:"error"`
+	Code       string `json:"code"`
+	Resource   string `json:"resource,omitempty"`
+	Permission string `json:"permission,omitempty"`
+}
+
+// SubjectFunc はリクエストから subject を取り出す関数
+type SubjectFunc func(c *gin.Context) string
+
+// Guard は Authorizer を使って Gin のルートを保護する
+type Guard struct {
+	authorizer Authorizer
+	subject    SubjectFunc
+}
+
+// NewGuard は authorizer と subject の取得方法から Guard を生成する
+func NewGuard(authorizer Authorizer, subject SubjectFunc) *Guard {
+	return &Guard{authorizer: authorizer, subject: subject}
+}
+
+// Identify は subject を解決して gin.Context に保存するミドルウェア
+func (g *Guard) Identify() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(subjectKey); !ok {
+			c.Set(subjectKey, g.subject(c))
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission はパスパラメータ paramName のリソースに対して permission を要求するミドルウェア。
+// 判定結果と subject は gin.Context に保存され、拒否・エラー時は ErrorResponse を返して中断する
+func (g *Guard) RequirePermission(resourceType, paramName, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subject, ok := c.Get(subjectKey)
+		if !ok {
+			subject = g.subject(c)
+			c.Set(subjectKey, subject)
+		}
+
+		req := Request{
+			Subject:      subject.(string),
+			ResourceType: resourceType,
+			ResourceID:   c.Param(paramName),
+			Permission:   permission,
+		}
+		resource := req.ResourceType + ":" + req.ResourceID
+
+		allowed, err := g.authorizer.Check(c, req)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, ErrorResponse{
+				Error:      "認可チェックエラー: " + err.Error(),
+				Code:       "authorization_error",
+				Resource:   resource,
+				Permission: permission,
+			})
+			return
+		}
+
+		c.Set(decisionKey, Decision{Request: req, Allowed: allowed})
+		if !allowed {
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{
+				Error:      "アクセスが拒否されました",
+				Code:       "forbidden",
+				Resource:   resource,
+				Permission: permission,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Route はエンドポイントと、その実行に必要な権限の定義
+type Route struct {
+	Method       string
+	Path         string
+	ResourceType string // 認可対象のリソース種別（空の場合はハンドラ内で判定）
+	Param        string // リソースIDを取得するパスパラメータ
+	Permission   string
+	Handler      gin.HandlerFunc
+}
+
+// Mount はルート表の各エンドポイントを、必要な権限を要求するミドルウェア付きで api に登録する
+func (g *Guard) Mount(api *gin.RouterGroup, routes []Route) {
+	api.Use(g.Identify())
+	for _, rt := range routes {
+		if rt.ResourceType == "" {
+			api.Handle(rt.Method, rt.Path, rt.Handler)
+			continue
+		}
+		api.Handle(rt.Method, rt.Path, g.RequirePermission(rt.ResourceType, rt.Param, rt.Permission), rt.Handler)
+	}
+}
+
+// SubjectFrom は Guard が保存した subject を返す
+func SubjectFrom(c *gin.Context) string {
+	return c.GetString(subjectKey)
+}
+
+// DecisionFrom は RequirePermission が保存した判定結果を返す
+func DecisionFrom(c *gin.Context) (Decision, bool) {
+	v, ok := c.Get(decisionKey)
+	if !ok {
+		return Decision{}, false
+	}
+	d, ok := v.(Decision)
+	return d, ok
+}
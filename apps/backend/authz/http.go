@@ -0,0 +1,127 @@
+package authz
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// postJSON は body をJSONでPOSTし、レスポンスを out にデコードする
+func postJSON(ctx context.Context, client *http.Client, url string, header http.Header, body, out interface{}) error {
+	return doJSON(ctx, client, http.MethodPost, url, header, body, out)
+}
+
+// doJSON は body をJSONで送信し、レスポンスを out にデコードする。body / out が nil の場合は省略する
+func doJSON(ctx context.Context, client *http.Client, method, url string, header http.Header, body, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reqBody = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status: %d", url, resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+
+	// レスポンスを読み取り
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// JSONをパース
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// 認可サーバへの1リクエストの上限。応答しないサーバがハンドラや outbox ワーカーを止め続けないようにする
+const defaultTimeout = 5 * time.Second
+
+// Option は Authorizer の生成オプション
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	client *http.Client
+}
+
+// WithHTTPClient は認可サーバへの通信に使う http.Client を指定する（mTLS など）
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *clientOptions) {
+		o.client = client
+	}
+}
+
+func applyOptions(opts []Option) clientOptions {
+	o := clientOptions{client: &http.Client{Timeout: defaultTimeout}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// bearer は preshared key を Authorization ヘッダーとして返す。key が空の場合は nil
+func bearer(key string) http.Header {
+	if key == "" {
+		return nil
+	}
+	return http.Header{"Authorization": []string{"Bearer " + key}}
+}
+
+// NewMTLSClient はクライアント証明書で認可サーバに接続する http.Client を生成する。
+// caFile が空の場合はシステムのルート証明書でサーバ証明書を検証する
+func NewMTLSClient(certFile, keyFile, caFile string) (*http.Client, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if caFile != "" {
+		caPEM, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: transport, Timeout: defaultTimeout}, nil
+}
@@ -0,0 +1,99 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// メンバーのロール
+const (
+	RoleOwner   = "owner"
+	RoleManager = "manager"
+	RoleStaff   = "staff"
+)
+
+// MembershipChange は1件のメンバー変更。
+// OldRole が空の場合は追加、NewRole が空の場合は削除、両方ある場合はロール変更を表す
+type MembershipChange struct {
+	ResourceType string
+	ResourceID   string
+	Subject      string
+	OldRole      string
+	NewRole      string
+}
+
+// MembershipWriter は認可エンジンのストアへメンバー変更を書き込む
+type MembershipWriter interface {
+	Name() string
+	// ApplyMembership は変更を反映する。同じ変更を繰り返し適用しても結果が変わらないこと
+	ApplyMembership(ctx context.Context, change MembershipChange) error
+}
+
+// Membership は1件のロール割り当て
+type Membership struct {
+	ResourceType string `json:"resource_type"`
+	ResourceID   string `json:"resource_id"`
+	Subject      string `json:"subject"`
+	Role         string `json:"role"`
+}
+
+func (m Membership) String() string {
+	return fmt.Sprintf("%s:%s %s %s", m.ResourceType, m.ResourceID, m.Subject, m.Role)
+}
+
+// MembershipReader は認可エンジンのストアにあるロール割り当てを読み出す（system / aws のみ）
+type MembershipReader interface {
+	Name() string
+	ListMemberships(ctx context.Context) ([]Membership, error)
+}
+
+// DiffMemberships は want（DB）にあって got（エンジン）にない割り当てと、got にだけある割り当てを返す
+func DiffMemberships(want, got []Membership) (missing, extra []Membership) {
+	wantSet := make(map[Membership]bool, len(want))
+	for _, m := range want {
+		wantSet[m] = true
+	}
+	gotSet := make(map[Membership]bool, len(got))
+	for _, m := range got {
+		gotSet[m] = true
+	}
+
+	for _, m := range want {
+		if !gotSet[m] {
+			missing = append(missing, m)
+			gotSet[m] = true // 重複を報告しない
+		}
+	}
+	for _, m := range got {
+		if !wantSet[m] {
+			extra = append(extra, m)
+			wantSet[m] = true
+		}
+	}
+	sortMemberships(missing)
+	sortMemberships(extra)
+	return missing, extra
+}
+
+func sortMemberships(ms []Membership) {
+	sort.Slice(ms, func(i, j int) bool {
+		a, b := ms[i], ms[j]
+		if a.ResourceType != b.ResourceType {
+			return a.ResourceType < b.ResourceType
+		}
+		if a.ResourceID != b.ResourceID {
+			return a.ResourceID < b.ResourceID
+		}
+		if a.Subject != b.Subject {
+			return a.Subject < b.Subject
+		}
+		return a.Role < b.Role
+	})
+}
+
+// isMembershipRole は system / aws のロールかどうかを返す
+func isMembershipRole(resourceType, role string) bool {
+	_, ok := RolePermissions[resourceType][role]
+	return ok
+}
@@ -0,0 +1,129 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// OPA 認可用の構造体
+type opaAuthRequest struct {
+	Subject    string `json:"subject"`
+	Resource   string `json:"resource"`
+	Permission string `json:"permission"`
+}
+
+type opaAuthResponse struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type opaMembershipRequest struct {
+	Role string `json:"role"`
+}
+
+type opaBatchAuthRequest struct {
+	Requests []opaAuthRequest `json:"requests"`
+}
+
+type opaBatchAuthResponse struct {
+	Results []opaAuthResponse `json:"results"`
+}
+
+// OPAAuthorizer は OPA 認可サーバを使う Authorizer。
+// グローバル管理者の判定はポリシー側（user_global_roles）で行われる
+type OPAAuthorizer struct {
+	baseURL string
+	authKey string
+	client  *http.Client
+}
+
+// NewOPAAuthorizer は baseURL の OPA 認可サーバを authKey（preshared key）で使う Authorizer を生成する
+func NewOPAAuthorizer(baseURL, authKey string, opts ...Option) *OPAAuthorizer {
+	o := applyOptions(opts)
+	return &OPAAuthorizer{baseURL: baseURL, authKey: authKey, client: o.client}
+}
+
+func (a *OPAAuthorizer) Name() string { return "opa" }
+
+func (a *OPAAuthorizer) Check(ctx context.Context, req Request) (bool, error) {
+	var authResp opaAuthResponse
+	err := postJSON(ctx, a.client, a.baseURL+"/authorize", bearer(a.authKey), toOPARequest(req), &authResp)
+	if err != nil {
+		return false, fmt.Errorf("OPA authorization failed: %w", err)
+	}
+	return authResp.Allowed, nil
+}
+
+// BulkCheck は /authorize/batch で複数の認可チェックを1回の呼び出しで行う
+func (a *OPAAuthorizer) BulkCheck(ctx context.Context, reqs []Request) ([]bool, error) {
+	return inBatches(reqs, func(batch []Request) ([]bool, error) {
+		batchReq := opaBatchAuthRequest{Requests: make([]opaAuthRequest, len(batch))}
+		for i, req := range batch {
+			batchReq.Requests[i] = toOPARequest(req)
+		}
+
+		var batchResp opaBatchAuthResponse
+		if err := postJSON(ctx, a.client, a.baseURL+"/authorize/batch", bearer(a.authKey), batchReq, &batchResp); err != nil {
+			return nil, fmt.Errorf("OPA batch authorization failed: %w", err)
+		}
+
+		results := make([]bool, len(batchResp.Results))
+		for i, r := range batchResp.Results {
+			results[i] = r.Allowed
+		}
+		return results, nil
+	})
+}
+
+// toOPARequest は共通モデルを OPA の (subject, "type:id", permission) に変換する
+func toOPARequest(req Request) opaAuthRequest {
+	return opaAuthRequest{
+		Subject:    req.Subject,
+		Resource:   req.ResourceType + ":" + req.ResourceID,
+		Permission: req.Permission,
+	}
+}
+
+func (a *OPAAuthorizer) LookupResources(ctx context.Context, subject, resourceType, permission string) (ResourceSet, error) {
+	return ResourceSet{}, ErrNotSupported
+}
+
+// ApplyMembership は OPA サーバのメンバーシップデータ（data.memberships）を更新する
+func (a *OPAAuthorizer) ApplyMembership(ctx context.Context, change MembershipChange) error {
+	endpoint := a.baseURL + "/memberships/" + url.PathEscape(change.ResourceType) + "/" +
+		url.PathEscape(change.ResourceID) + "/" + url.PathEscape(change.Subject)
+
+	var err error
+	if change.NewRole != "" {
+		err = doJSON(ctx, a.client, http.MethodPut, endpoint, bearer(a.authKey), opaMembershipRequest{Role: change.NewRole}, nil)
+	} else {
+		err = doJSON(ctx, a.client, http.MethodDelete, endpoint, bearer(a.authKey), nil, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("OPA membership update failed: %w", err)
+	}
+	return nil
+}
+
+// ListMemberships はデータドキュメント data.memberships からロール割り当てを読み出す
+func (a *OPAAuthorizer) ListMemberships(ctx context.Context) ([]Membership, error) {
+	// リソース種別 → ユーザー → リソースID → ロール
+	var resp struct {
+		Memberships map[string]map[string]map[string]string `json:"memberships"`
+	}
+	if err := doJSON(ctx, a.client, http.MethodGet, a.baseURL+"/memberships", bearer(a.authKey), nil, &resp); err != nil {
+		return nil, fmt.Errorf("OPA list memberships failed: %w", err)
+	}
+
+	var memberships []Membership
+	for resourceType, subjects := range resp.Memberships {
+		for subject, roles := range subjects {
+			for resourceID, role := range roles {
+				memberships = append(memberships, Membership{ResourceType: resourceType, ResourceID: resourceID, Subject: subject, Role: role})
+			}
+		}
+	}
+	return memberships, nil
+}
@@ -0,0 +1,224 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// OutboxEvent は DB のリレーションと同じトランザクションで書き込まれた、認可エンジンへ未反映のメンバー変更
+type OutboxEvent struct {
+	ID        int64
+	Change    MembershipChange
+	Delivered []string // 反映済みのエンジン名。再試行時はこれ以外のエンジンにのみ反映する
+	Attempts  int
+}
+
+// OutboxLag は未反映のイベント数と、最も古いイベントの経過時間
+type OutboxLag struct {
+	Pending       int64   `json:"pending"`
+	OldestSeconds float64 `json:"oldest_seconds"`
+	DeadLetters   int64   `json:"dead_letters"`
+}
+
+// OutboxStore は outbox テーブルの操作。PostgreSQL では PostgresOutboxStore を使う
+type OutboxStore interface {
+	// ClaimOutboxEvents は反映可能なイベントを最大 limit 件取得し、lease の間は他のワーカーが取得しないようにする。
+	// 同じメンバーに対する古いイベントや dead-letter が残っている場合、新しいイベントは返さない
+	// （一部のエンジンにだけ反映された変更の上に後続の変更を重ねないため）
+	ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]OutboxEvent, error)
+	RecordOutboxDelivery(ctx context.Context, id int64, delivered []string) error
+	CompleteOutboxEvent(ctx context.Context, id int64) error
+	RetryOutboxEvent(ctx context.Context, id int64, attempts int, delay time.Duration, lastErr string) error
+	// DeadLetterOutboxEvent はイベントを dead-letter テーブルへ移す。同じメンバーの後続イベントは dead-letter が削除されるまで保留される
+	DeadLetterOutboxEvent(ctx context.Context, id int64, attempts int, lastErr string) error
+	OutboxLag(ctx context.Context) (OutboxLag, error)
+}
+
+// OutboxOptions は OutboxWorker の設定
+type OutboxOptions struct {
+	PollInterval time.Duration // 新しいイベントを確認する間隔
+	BatchSize    int           // 1回に取得するイベント数
+	Lease        time.Duration // 取得したイベントを他のワーカーに渡さない期間
+	MaxAttempts  int           // この回数失敗したイベントは dead-letter テーブルへ移す
+	BaseBackoff  time.Duration // 再試行までの待ち時間（失敗ごとに倍になる）
+	MaxBackoff   time.Duration
+}
+
+// OutboxOptionsFromEnv は outbox ワーカーの設定を環境変数（AUTHZ_OUTBOX_*）から構築する
+func OutboxOptionsFromEnv() (OutboxOptions, error) {
+	opts := OutboxOptions{
+		PollInterval: time.Second,
+		BatchSize:    100,
+		Lease:        30 * time.Second,
+		MaxAttempts:  10,
+		BaseBackoff:  time.Second,
+		MaxBackoff:   5 * time.Minute,
+	}
+
+	if v := os.Getenv("AUTHZ_OUTBOX_POLL_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return opts, fmt.Errorf("invalid AUTHZ_OUTBOX_POLL_INTERVAL: %q", v)
+		}
+		opts.PollInterval = d
+	}
+	if v := os.Getenv("AUTHZ_OUTBOX_MAX_ATTEMPTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return opts, fmt.Errorf("invalid AUTHZ_OUTBOX_MAX_ATTEMPTS: %q", v)
+		}
+		opts.MaxAttempts = n
+	}
+	if v := os.Getenv("AUTHZ_OUTBOX_MAX_BACKOFF"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return opts, fmt.Errorf("invalid AUTHZ_OUTBOX_MAX_BACKOFF: %q", v)
+		}
+		opts.MaxBackoff = d
+	}
+
+	return opts, nil
+}
+
+// OutboxWorker は outbox のイベントを各認可エンジンへ順に反映するバックグラウンドワーカー
+type OutboxWorker struct {
+	store   OutboxStore
+	writers []MembershipWriter
+	cache   *DecisionCache
+	opts    OutboxOptions
+	wake    chan struct{}
+}
+
+// NewOutboxWorker は writers へ反映する OutboxWorker を生成する。cache は反映後に無効化される
+func NewOutboxWorker(store OutboxStore, cache *DecisionCache, opts OutboxOptions, writers ...MembershipWriter) *OutboxWorker {
+	return &OutboxWorker{
+		store:   store,
+		writers: writers,
+		cache:   cache,
+		opts:    opts,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Notify は新しいイベントが書き込まれたことをワーカーに知らせる（PollInterval を待たずに処理する）
+func (w *OutboxWorker) Notify() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Lag は outbox の遅延状況を返す
+func (w *OutboxWorker) Lag(ctx context.Context) (OutboxLag, error) {
+	return w.store.OutboxLag(ctx)
+}
+
+// Run は ctx がキャンセルされるまで outbox を処理する
+func (w *OutboxWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// 取得件数が BatchSize に達している間は続けて処理する
+		for {
+			n, err := w.processBatch(ctx)
+			if err != nil {
+				log.Printf("outbox の取得に失敗しました: %v", err)
+				break
+			}
+			if n < w.opts.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.wake:
+		}
+	}
+}
+
+func (w *OutboxWorker) processBatch(ctx context.Context) (int, error) {
+	events, err := w.store.ClaimOutboxEvents(ctx, w.opts.BatchSize, w.opts.Lease)
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+
+	for _, ev := range events {
+		w.deliver(ctx, ev)
+	}
+	return len(events), nil
+}
+
+// deliver は未反映のエンジンへイベントを反映する。失敗した場合は再試行を予約し、上限に達したら dead-letter へ移す
+func (w *OutboxWorker) deliver(ctx context.Context, ev OutboxEvent) {
+	defer w.invalidate(ev.Change)
+
+	done := make(map[string]bool, len(ev.Delivered))
+	for _, name := range ev.Delivered {
+		done[name] = true
+	}
+
+	for _, writer := range w.writers {
+		if done[writer.Name()] {
+			continue
+		}
+		if err := writer.ApplyMembership(ctx, ev.Change); err != nil {
+			w.fail(ctx, ev, fmt.Errorf("%s: %w", writer.Name(), err))
+			return
+		}
+		ev.Delivered = append(ev.Delivered, writer.Name())
+		if err := w.store.RecordOutboxDelivery(ctx, ev.ID, ev.Delivered); err != nil {
+			// リース切れ後に再取得される。各エンジンへの書き込みは冪等なので再送しても問題ない
+			log.Printf("outbox イベント %d の反映状況の記録に失敗しました: %v", ev.ID, err)
+			return
+		}
+	}
+
+	if err := w.store.CompleteOutboxEvent(ctx, ev.ID); err != nil {
+		log.Printf("outbox イベント %d の完了の記録に失敗しました: %v", ev.ID, err)
+	}
+}
+
+func (w *OutboxWorker) fail(ctx context.Context, ev OutboxEvent, cause error) {
+	attempts := ev.Attempts + 1
+	if attempts >= w.opts.MaxAttempts {
+		log.Printf("outbox イベント %d を dead-letter へ移します（%d 回失敗）: %v", ev.ID, attempts, cause)
+		if err := w.store.DeadLetterOutboxEvent(ctx, ev.ID, attempts, cause.Error()); err != nil {
+			log.Printf("outbox イベント %d の dead-letter への移動に失敗しました: %v", ev.ID, err)
+		}
+		return
+	}
+
+	delay := w.backoff(attempts)
+	log.Printf("outbox イベント %d の反映に失敗しました（%d 回目、%s 後に再試行）: %v", ev.ID, attempts, delay, cause)
+	if err := w.store.RetryOutboxEvent(ctx, ev.ID, attempts, delay, cause.Error()); err != nil {
+		log.Printf("outbox イベント %d の再試行の予約に失敗しました: %v", ev.ID, err)
+	}
+}
+
+// backoff は attempts 回目の失敗後の待ち時間（BaseBackoff * 2^(attempts-1)、MaxBackoff まで）
+func (w *OutboxWorker) backoff(attempts int) time.Duration {
+	delay := w.opts.BaseBackoff
+	for i := 1; i < attempts && delay < w.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > w.opts.MaxBackoff {
+		delay = w.opts.MaxBackoff
+	}
+	return delay
+}
+
+func (w *OutboxWorker) invalidate(change MembershipChange) {
+	if w.cache != nil {
+		w.cache.InvalidateResource(change.ResourceType, change.ResourceID)
+	}
+}
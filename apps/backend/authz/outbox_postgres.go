@@ -0,0 +1,134 @@
+package authz
+
+import (
+	"authz/db/sqlc"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresOutboxStore は OutboxStore を query/outbox/init.sql のテーブルで実装する。
+// 各サービスの DB に同じテーブルを作成し、そのコネクションプールを渡して使う
+type PostgresOutboxStore struct {
+	queries *sqlc.Queries
+}
+
+// NewPostgresOutboxStore は db（*pgxpool.Pool など）の outbox テーブルを使う PostgresOutboxStore を生成する
+func NewPostgresOutboxStore(db sqlc.DBTX) *PostgresOutboxStore {
+	return &PostgresOutboxStore{queries: sqlc.New(db)}
+}
+
+// EnqueueMembershipChange はメンバー変更を outbox に書き込む。tx にはリレーションを書き込んだトランザクションを渡す
+func EnqueueMembershipChange(ctx context.Context, tx sqlc.DBTX, change MembershipChange) error {
+	if change.OldRole == change.NewRole {
+		// ロールが変わらない場合はエンジンへの反映は不要
+		return nil
+	}
+	return sqlc.New(tx).EnqueueOutboxEvent(ctx, sqlc.EnqueueOutboxEventParams{
+		ResourceType: change.ResourceType,
+		ResourceID:   change.ResourceID,
+		Subject:      change.Subject,
+		OldRole:      change.OldRole,
+		NewRole:      change.NewRole,
+	})
+}
+
+// PostgresOutbox はリレーションと outbox を同じトランザクションで書き込み、ワーカーで各認可エンジンへ反映する
+type PostgresOutbox struct {
+	*OutboxWorker
+	db *pgxpool.Pool
+}
+
+// StartPostgresOutbox は db の outbox テーブルを使うワーカーを、環境変数の設定で ctx がキャンセルされるまで動かす
+func StartPostgresOutbox(ctx context.Context, db *pgxpool.Pool, cache *DecisionCache, writers ...MembershipWriter) (*PostgresOutbox, error) {
+	opts, err := OutboxOptionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	worker := NewOutboxWorker(NewPostgresOutboxStore(db), cache, opts, writers...)
+	go worker.Run(ctx)
+	return &PostgresOutbox{OutboxWorker: worker, db: db}, nil
+}
+
+// WriteMembership は write でリレーションを変更し、同じトランザクションで outbox にメンバー変更を書き込む。
+// 各認可エンジンへの反映は outbox ワーカーが行う
+func (o *PostgresOutbox) WriteMembership(ctx context.Context, write func(tx pgx.Tx) (MembershipChange, error)) error {
+	tx, err := o.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) // コミット後は何もしない
+
+	change, err := write(tx)
+	if err != nil {
+		return err
+	}
+	if err := EnqueueMembershipChange(ctx, tx, change); err != nil {
+		return fmt.Errorf("failed to enqueue membership change: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	o.Notify()
+	return nil
+}
+
+func (s *PostgresOutboxStore) ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]OutboxEvent, error) {
+	rows, err := s.queries.ClaimOutboxEvents(ctx, sqlc.ClaimOutboxEventsParams{
+		LeaseSeconds: lease.Seconds(),
+		BatchSize:    int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]OutboxEvent, len(rows))
+	for i, row := range rows {
+		events[i] = OutboxEvent{
+			ID: row.ID,
+			Change: MembershipChange{
+				ResourceType: row.ResourceType,
+				ResourceID:   row.ResourceID,
+				Subject:      row.Subject,
+				OldRole:      row.OldRole,
+				NewRole:      row.NewRole,
+			},
+			Delivered: row.DeliveredEngines,
+			Attempts:  int(row.Attempts),
+		}
+	}
+	return events, nil
+}
+
+func (s *PostgresOutboxStore) RecordOutboxDelivery(ctx context.Context, id int64, delivered []string) error {
+	return s.queries.RecordOutboxDelivery(ctx, sqlc.RecordOutboxDeliveryParams{ID: id, DeliveredEngines: delivered})
+}
+
+func (s *PostgresOutboxStore) CompleteOutboxEvent(ctx context.Context, id int64) error {
+	return s.queries.CompleteOutboxEvent(ctx, id)
+}
+
+func (s *PostgresOutboxStore) RetryOutboxEvent(ctx context.Context, id int64, attempts int, delay time.Duration, lastErr string) error {
+	return s.queries.RetryOutboxEvent(ctx, sqlc.RetryOutboxEventParams{
+		Attempts:     int32(attempts),
+		LastError:    lastErr,
+		DelaySeconds: delay.Seconds(),
+		ID:           id,
+	})
+}
+
+func (s *PostgresOutboxStore) DeadLetterOutboxEvent(ctx context.Context, id int64, attempts int, lastErr string) error {
+	return s.queries.DeadLetterOutboxEvent(ctx, sqlc.DeadLetterOutboxEventParams{ID: id, Attempts: int32(attempts), LastError: lastErr})
+}
+
+func (s *PostgresOutboxStore) OutboxLag(ctx context.Context) (OutboxLag, error) {
+	row, err := s.queries.GetOutboxLag(ctx)
+	if err != nil {
+		return OutboxLag{}, err
+	}
+	return OutboxLag{Pending: row.Pending, OldestSeconds: row.OldestSeconds, DeadLetters: row.DeadLetters}, nil
+}
@@ -0,0 +1,18 @@
+// Code generated by authorization/modelgen from authorization/model.yaml. DO NOT EDIT.
+
+package authz
+
+// RolePermissions はリソース種別ごとのロールと（継承を含めた）権限の対応。
+// メンバー変更やエンジンから読み出したロール割り当ての検証に使う
+var RolePermissions = map[string]map[string][]string{
+	"system": {
+		"owner":   {"read", "write", "delete", "manage_members"},
+		"manager": {"read", "write", "delete"},
+		"staff":   {"read"},
+	},
+	"aws": {
+		"owner":   {"read", "write", "delete", "manage_members"},
+		"manager": {"read"},
+		"staff":   {"read"},
+	},
+}
@@ -0,0 +1,371 @@
+package authz
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// グローバル管理者の判定に使うリソースと権限
+const (
+	spiceDBGlobalResourceType = "global"
+	spiceDBGlobalResourceID   = "main"
+	spiceDBGlobalPermission   = "full_access"
+)
+
+// 公式SpiceDB API用の構造体
+type spiceDBObjectReference struct {
+	ObjectType string `json:"objectType"`
+	ObjectId   string `json:"objectId"`
+}
+
+type spiceDBSubjectReference struct {
+	Object spiceDBObjectReference `json:"object"`
+}
+
+type spiceDBCheckRequest struct {
+	Resource   spiceDBObjectReference  `json:"resource"`
+	Permission string                  `json:"permission"`
+	Subject    spiceDBSubjectReference `json:"subject"`
+}
+
+type spiceDBCheckResponse struct {
+	Permissionship string `json:"permissionship"`
+}
+
+type spiceDBCheckBulkRequest struct {
+	Items []spiceDBCheckRequest `json:"items"`
+}
+
+// CheckBulkPermissions の結果は items と同じ順序で pairs に返る
+type spiceDBCheckBulkResponse struct {
+	Pairs []struct {
+		Item *struct {
+			Permissionship string `json:"permissionship"`
+		} `json:"item"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	} `json:"pairs"`
+}
+
+type spiceDBRelationship struct {
+	Resource spiceDBObjectReference  `json:"resource"`
+	Relation string                  `json:"relation"`
+	Subject  spiceDBSubjectReference `json:"subject"`
+}
+
+type spiceDBRelationshipUpdate struct {
+	Operation    string              `json:"operation"`
+	Relationship spiceDBRelationship `json:"relationship"`
+}
+
+type spiceDBWriteRelationshipsRequest struct {
+	Updates []spiceDBRelationshipUpdate `json:"updates"`
+}
+
+type spiceDBWriteRelationshipsResponse struct {
+	WrittenAt struct {
+		Token string `json:"token"`
+	} `json:"writtenAt"`
+}
+
+type spiceDBLookupResourcesRequest struct {
+	ResourceObjectType string                  `json:"resourceObjectType"`
+	Permission         string                  `json:"permission"`
+	Subject            spiceDBSubjectReference `json:"subject"`
+}
+
+// LookupResources はストリームで1行ずつ result が返る
+type spiceDBLookupResourcesResponse struct {
+	Result *struct {
+		ResourceObjectId string `json:"resourceObjectId"`
+		Permissionship   string `json:"permissionship"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type spiceDBReadRelationshipsRequest struct {
+	RelationshipFilter struct {
+		ResourceType string `json:"resourceType"`
+	} `json:"relationshipFilter"`
+}
+
+// ReadRelationships もストリームで1行ずつ result が返る
+type spiceDBReadRelationshipsResponse struct {
+	Result *struct {
+		Relationship spiceDBRelationship `json:"relationship"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// SpiceDBAuthorizer は SpiceDB の HTTP API を使う Authorizer。
+// スキーマ上 global と system/aws はつながっていないため、グローバル管理者は個別に判定する
+type SpiceDBAuthorizer struct {
+	baseURL string
+	authKey string
+	client  *http.Client
+}
+
+// NewSpiceDBAuthorizer は baseURL の SpiceDB を authKey（preshared key）で使う Authorizer を生成する
+func NewSpiceDBAuthorizer(baseURL, authKey string, opts ...Option) *SpiceDBAuthorizer {
+	o := applyOptions(opts)
+	return &SpiceDBAuthorizer{baseURL: baseURL, authKey: authKey, client: o.client}
+}
+
+func (a *SpiceDBAuthorizer) Name() string { return "spicedb" }
+
+func subjectRef(subject string) spiceDBSubjectReference {
+	return spiceDBSubjectReference{Object: spiceDBObjectReference{ObjectType: "user", ObjectId: subject}}
+}
+
+// checkPermission は SpiceDB へ1件の CheckPermission を行う
+func (a *SpiceDBAuthorizer) checkPermission(ctx context.Context, req Request) (bool, error) {
+	var checkResp spiceDBCheckResponse
+	err := postJSON(ctx, a.client, a.baseURL+"/v1/permissions/check", bearer(a.authKey), spiceDBCheckRequest{
+		Resource:   spiceDBObjectReference{ObjectType: req.ResourceType, ObjectId: req.ResourceID},
+		Permission: req.Permission,
+		Subject:    subjectRef(req.Subject),
+	}, &checkResp)
+	if err != nil {
+		return false, fmt.Errorf("SpiceDB authorization failed: %w", err)
+	}
+
+	// PERMISSIONSHIP_HAS_PERMISSIONの場合は権限あり
+	return checkResp.Permissionship == "PERMISSIONSHIP_HAS_PERMISSION", nil
+}
+
+// isGlobalAdmin はグローバル管理者権限をチェックする
+func (a *SpiceDBAuthorizer) isGlobalAdmin(ctx context.Context, subject string) (bool, error) {
+	return a.checkPermission(ctx, Request{
+		Subject:      subject,
+		ResourceType: spiceDBGlobalResourceType,
+		ResourceID:   spiceDBGlobalResourceID,
+		Permission:   spiceDBGlobalPermission,
+	})
+}
+
+func (a *SpiceDBAuthorizer) Check(ctx context.Context, req Request) (bool, error) {
+	// まずグローバル管理者権限をチェック
+	if globalAdmin, err := a.isGlobalAdmin(ctx, req.Subject); err == nil && globalAdmin {
+		return true, nil
+	}
+
+	// 通常の権限チェック
+	return a.checkPermission(ctx, req)
+}
+
+// BulkCheck は CheckBulkPermissions で複数の認可チェックを1回の呼び出しで行う。
+// グローバル管理者の判定も subject ごとに同じ呼び出しへ含める
+func (a *SpiceDBAuthorizer) BulkCheck(ctx context.Context, reqs []Request) ([]bool, error) {
+	return inBatches(reqs, func(batch []Request) ([]bool, error) {
+		subjects := []string{}
+		seen := map[string]bool{}
+		for _, req := range batch {
+			if !seen[req.Subject] {
+				seen[req.Subject] = true
+				subjects = append(subjects, req.Subject)
+			}
+		}
+
+		items := make([]spiceDBCheckRequest, 0, len(subjects)+len(batch))
+		for _, subject := range subjects {
+			items = append(items, spiceDBCheckRequest{
+				Resource:   spiceDBObjectReference{ObjectType: spiceDBGlobalResourceType, ObjectId: spiceDBGlobalResourceID},
+				Permission: spiceDBGlobalPermission,
+				Subject:    subjectRef(subject),
+			})
+		}
+		for _, req := range batch {
+			items = append(items, spiceDBCheckRequest{
+				Resource:   spiceDBObjectReference{ObjectType: req.ResourceType, ObjectId: req.ResourceID},
+				Permission: req.Permission,
+				Subject:    subjectRef(req.Subject),
+			})
+		}
+
+		var bulkResp spiceDBCheckBulkResponse
+		err := postJSON(ctx, a.client, a.baseURL+"/v1/permissions/checkbulk", bearer(a.authKey), spiceDBCheckBulkRequest{Items: items}, &bulkResp)
+		if err != nil {
+			return nil, fmt.Errorf("SpiceDB bulk authorization failed: %w", err)
+		}
+		if len(bulkResp.Pairs) != len(items) {
+			return nil, fmt.Errorf("SpiceDB bulk authorization returned %d results for %d items", len(bulkResp.Pairs), len(items))
+		}
+
+		allowed := make([]bool, len(items))
+		for i, pair := range bulkResp.Pairs {
+			if pair.Error != nil {
+				return nil, fmt.Errorf("SpiceDB bulk authorization failed: %s", pair.Error.Message)
+			}
+			allowed[i] = pair.Item != nil && pair.Item.Permissionship == "PERMISSIONSHIP_HAS_PERMISSION"
+		}
+
+		globalAdmin := map[string]bool{}
+		for i, subject := range subjects {
+			globalAdmin[subject] = allowed[i]
+		}
+		results := make([]bool, len(batch))
+		for i, req := range batch {
+			results[i] = globalAdmin[req.Subject] || allowed[len(subjects)+i]
+		}
+		return results, nil
+	})
+}
+
+func (a *SpiceDBAuthorizer) LookupResources(ctx context.Context, subject, resourceType, permission string) (ResourceSet, error) {
+	// グローバル管理者は全リソースが対象
+	if globalAdmin, err := a.isGlobalAdmin(ctx, subject); err == nil && globalAdmin {
+		return ResourceSet{All: true}, nil
+	}
+
+	jsonData, err := json.Marshal(spiceDBLookupResourcesRequest{
+		ResourceObjectType: resourceType,
+		Permission:         permission,
+		Subject:            subjectRef(subject),
+	})
+	if err != nil {
+		return ResourceSet{}, fmt.Errorf("failed to marshal SpiceDB lookup request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/v1/permissions/resources", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return ResourceSet{}, fmt.Errorf("failed to create SpiceDB request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+a.authKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return ResourceSet{}, fmt.Errorf("failed to call SpiceDB service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ResourceSet{}, fmt.Errorf("SpiceDB service returned status: %d", resp.StatusCode)
+	}
+
+	set := ResourceSet{IDs: []string{}}
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var line spiceDBLookupResourcesResponse
+		if err := decoder.Decode(&line); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return ResourceSet{}, fmt.Errorf("failed to decode SpiceDB lookup response: %w", err)
+		}
+		if line.Error != nil {
+			return ResourceSet{}, fmt.Errorf("SpiceDB lookup failed: %s", line.Error.Message)
+		}
+		if line.Result != nil && line.Result.Permissionship == "LOOKUP_PERMISSIONSHIP_HAS_PERMISSION" {
+			set.IDs = append(set.IDs, line.Result.ResourceObjectId)
+		}
+	}
+	return set, nil
+}
+
+// ApplyMembership はロールのリレーションを WriteRelationships で付け替える（1回の呼び出しでアトミックに反映される）
+func (a *SpiceDBAuthorizer) ApplyMembership(ctx context.Context, change MembershipChange) error {
+	relationship := func(role string) spiceDBRelationship {
+		return spiceDBRelationship{
+			Resource: spiceDBObjectReference{ObjectType: change.ResourceType, ObjectId: change.ResourceID},
+			Relation: role,
+			Subject:  subjectRef(change.Subject),
+		}
+	}
+
+	var updates []spiceDBRelationshipUpdate
+	if change.OldRole != "" {
+		updates = append(updates, spiceDBRelationshipUpdate{Operation: "OPERATION_DELETE", Relationship: relationship(change.OldRole)})
+	}
+	if change.NewRole != "" {
+		updates = append(updates, spiceDBRelationshipUpdate{Operation: "OPERATION_TOUCH", Relationship: relationship(change.NewRole)})
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	var writeResp spiceDBWriteRelationshipsResponse
+	err := postJSON(ctx, a.client, a.baseURL+"/v1/relationships/write", bearer(a.authKey), spiceDBWriteRelationshipsRequest{Updates: updates}, &writeResp)
+	if err != nil {
+		return fmt.Errorf("SpiceDB write relationships failed: %w", err)
+	}
+	return nil
+}
+
+// ListMemberships は system / aws の owner / manager / staff リレーションを読み出す
+func (a *SpiceDBAuthorizer) ListMemberships(ctx context.Context) ([]Membership, error) {
+	var memberships []Membership
+	for _, resourceType := range []string{ResourceSystem, ResourceAWS} {
+		relationships, err := a.readRelationships(ctx, resourceType)
+		if err != nil {
+			return nil, err
+		}
+		for _, rel := range relationships {
+			if rel.Subject.Object.ObjectType != "user" || !isMembershipRole(resourceType, rel.Relation) {
+				continue
+			}
+			memberships = append(memberships, Membership{
+				ResourceType: resourceType,
+				ResourceID:   rel.Resource.ObjectId,
+				Subject:      rel.Subject.Object.ObjectId,
+				Role:         rel.Relation,
+			})
+		}
+	}
+	return memberships, nil
+}
+
+func (a *SpiceDBAuthorizer) readRelationships(ctx context.Context, resourceType string) ([]spiceDBRelationship, error) {
+	var body spiceDBReadRelationshipsRequest
+	body.RelationshipFilter.ResourceType = resourceType
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal SpiceDB read request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/v1/relationships/read", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SpiceDB request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+a.authKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call SpiceDB service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("SpiceDB service returned status: %d", resp.StatusCode)
+	}
+
+	var relationships []spiceDBRelationship
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var line spiceDBReadRelationshipsResponse
+		if err := decoder.Decode(&line); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode SpiceDB read response: %w", err)
+		}
+		if line.Error != nil {
+			return nil, fmt.Errorf("SpiceDB read relationships failed: %s", line.Error.Message)
+		}
+		if line.Result != nil {
+			relationships = append(relationships, line.Result.Relationship)
+		}
+	}
+	return relationships, nil
+}
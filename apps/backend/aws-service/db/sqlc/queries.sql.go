@@ -11,6 +11,30 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addAwsAccountMember = `-- name: AddAwsAccountMember :one
+INSERT INTO aws_account_user_relation (id, aws_account_id, user_id, role)
+VALUES (gen_random_uuid()::text, $1, $2, $3)
+RETURNING id, aws_account_id, user_id, role
+`
+
+type AddAwsAccountMemberParams struct {
+	AwsAccountID string
+	UserID       string
+	Role         string
+}
+
+func (q *Queries) AddAwsAccountMember(ctx context.Context, arg AddAwsAccountMemberParams) (AwsAccountUserRelation, error) {
+	row := q.db.QueryRow(ctx, addAwsAccountMember, arg.AwsAccountID, arg.UserID, arg.Role)
+	var i AwsAccountUserRelation
+	err := row.Scan(
+		&i.ID,
+		&i.AwsAccountID,
+		&i.UserID,
+		&i.Role,
+	)
+	return i, err
+}
+
 const getAwsAccount = `-- name: GetAwsAccount :one
 SELECT id, name, note FROM aws_account WHERE id = $1
 `
@@ -63,7 +87,7 @@ func (q *Queries) GetAwsAccountBySystemId(ctx context.Context, systemID string)
 }
 
 const getAwsAccountUsersByAwsAccountId = `-- name: GetAwsAccountUsersByAwsAccountId :many
-SELECT t1.id, name, note, t2.id, aws_account_id, user_id FROM aws_account t1 left join aws_account_user_relation t2 on t1.id = t2.aws_account_id where t2.aws_account_id = $1
+SELECT t1.id, name, note, t2.id, aws_account_id, user_id, role FROM aws_account t1 left join aws_account_user_relation t2 on t1.id = t2.aws_account_id where t2.aws_account_id = $1
 `
 
 type GetAwsAccountUsersByAwsAccountIdRow struct {
@@ -73,6 +97,7 @@ type GetAwsAccountUsersByAwsAccountIdRow struct {
 	ID_2         pgtype.Text
 	AwsAccountID pgtype.Text
 	UserID       pgtype.Text
+	Role         pgtype.Text
 }
 
 func (q *Queries) GetAwsAccountUsersByAwsAccountId(ctx context.Context, awsAccountID string) ([]GetAwsAccountUsersByAwsAccountIdRow, error) {
@@ -91,6 +116,7 @@ func (q *Queries) GetAwsAccountUsersByAwsAccountId(ctx context.Context, awsAccou
 			&i.ID_2,
 			&i.AwsAccountID,
 			&i.UserID,
+			&i.Role,
 		); err != nil {
 			return nil, err
 		}
@@ -126,6 +152,59 @@ func (q *Queries) GetAwsAccounts(ctx context.Context) ([]AwsAccount, error) {
 	return items, nil
 }
 
+const getAwsAccountsByIDs = `-- name: GetAwsAccountsByIDs :many
+SELECT id, name, note FROM aws_account WHERE id = ANY($1::text[])
+`
+
+func (q *Queries) GetAwsAccountsByIDs(ctx context.Context, ids []string) ([]AwsAccount, error) {
+	rows, err := q.db.Query(ctx, getAwsAccountsByIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AwsAccount
+	for rows.Next() {
+		var i AwsAccount
+		if err := rows.Scan(&i.ID, &i.Name, &i.Note); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAwsAccountMembers = `-- name: ListAwsAccountMembers :many
+SELECT id, aws_account_id, user_id, role FROM aws_account_user_relation ORDER BY aws_account_id, user_id
+`
+
+func (q *Queries) ListAwsAccountMembers(ctx context.Context) ([]AwsAccountUserRelation, error) {
+	rows, err := q.db.Query(ctx, listAwsAccountMembers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AwsAccountUserRelation
+	for rows.Next() {
+		var i AwsAccountUserRelation
+		if err := rows.Scan(
+			&i.ID,
+			&i.AwsAccountID,
+			&i.UserID,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateAwsAccount = `-- name: UpdateAwsAccount :one
 UPDATE aws_account SET name = $2, note = $3 WHERE id = $1 RETURNING id, name, note
 `
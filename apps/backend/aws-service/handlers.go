@@ -0,0 +1,257 @@
+package main
+
+import (
+	"authz"
+	"aws-service/db/sqlc"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// handlers はAWSアカウント関連APIのハンドラ。認可チェックはルート表のミドルウェアで行う
+type handlers struct {
+	queries    *sqlc.Queries
+	authorizer authz.Authorizer
+	cache      *authz.DecisionCache  // /health で統計を返す
+	outbox     *authz.PostgresOutbox // メンバー変更を書き込み、全認可エンジンへ反映する
+}
+
+// AWSアカウント一覧を取得するAPI（読み取り権限のあるアカウントのみ）
+func (h *handlers) listAwsAccounts(c *gin.Context) {
+	subject := authz.SubjectFrom(c)
+
+	// LookupResources に対応したエンジン（SpiceDB）は、読み取り可能なIDのみをDBから取得する
+	set, err := h.authorizer.LookupResources(c, subject, authz.ResourceAWS, authz.PermissionRead)
+	if err == nil {
+		var items []sqlc.AwsAccount
+		if set.All {
+			items, err = h.queries.GetAwsAccounts(c)
+		} else {
+			items, err = h.queries.GetAwsAccountsByIDs(c, set.IDs)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if items == nil {
+			items = []sqlc.AwsAccount{}
+		}
+		c.JSON(http.StatusOK, items)
+		return
+	}
+	if !errors.Is(err, authz.ErrNotSupported) {
+		c.JSON(http.StatusInternalServerError, authz.ErrorResponse{Error: fmt.Sprintf("認可チェックエラー: %v", err), Code: "authorization_error"})
+		return
+	}
+
+	// それ以外のエンジンは全AWSアカウントを取得して一括チェックで絞り込む
+	awsAccounts, err := h.queries.GetAwsAccounts(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// ユーザーがアクセス権限を持つAWSアカウントのみフィルタリング
+	ids := make([]string, len(awsAccounts))
+	for i, account := range awsAccounts {
+		ids[i] = account.ID
+	}
+	allowedIDs, err := authz.FilterAllowed(c, h.authorizer, subject, authz.ResourceAWS, authz.PermissionRead, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, authz.ErrorResponse{Error: fmt.Sprintf("認可チェックエラー: %v", err), Code: "authorization_error"})
+		return
+	}
+	allowed := make(map[string]bool, len(allowedIDs))
+	for _, id := range allowedIDs {
+		allowed[id] = true
+	}
+
+	allowedAccounts := []sqlc.AwsAccount{}
+	for _, account := range awsAccounts {
+		if allowed[account.ID] {
+			allowedAccounts = append(allowedAccounts, account)
+		}
+	}
+
+	c.JSON(http.StatusOK, allowedAccounts)
+}
+
+// AWSアカウント詳細を取得するAPI
+func (h *handlers) getAwsAccount(c *gin.Context) {
+	awsAccount, err := h.queries.GetAwsAccount(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, awsAccount)
+}
+
+// AWSアカウント更新API
+func (h *handlers) updateAwsAccount(c *gin.Context) {
+	awsAccountID := c.Param("id")
+
+	var req UpdateAwsAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	// アカウントの存在確認
+	if _, err := h.queries.GetAwsAccount(c, awsAccountID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "AWS account not found"})
+		return
+	}
+
+	// アカウント情報を更新
+	updatedAccount, err := h.queries.UpdateAwsAccount(c, sqlc.UpdateAwsAccountParams{
+		ID:   awsAccountID,
+		Name: req.Name,
+		Note: req.Note,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update account: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedAccount)
+}
+
+// システムIDでAWSアカウントを取得するAPI
+func (h *handlers) getAwsAccountsBySystem(c *gin.Context) {
+	awsAccounts, err := h.queries.GetAwsAccountBySystemId(c, c.Param("systemId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, awsAccounts)
+}
+
+// AWSアカウントに所属するユーザー一覧を取得するAPI
+func (h *handlers) getAwsAccountUsers(c *gin.Context) {
+	awsAccountID := c.Param("id")
+
+	// 1. AWSアカウントに関連するユーザーIDを取得
+	relations, err := h.queries.GetAwsAccountUsersByAwsAccountId(c, awsAccountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 2. UserIDsを抽出（nullチェック付き）
+	var userIDs []string
+	for _, rel := range relations {
+		if rel.UserID.Valid {
+			userIDs = append(userIDs, rel.UserID.String)
+		}
+	}
+
+	if len(userIDs) == 0 {
+		c.JSON(http.StatusOK, []AwsUserInfo{})
+		return
+	}
+
+	// 3. User Serviceから一括でユーザー情報を取得
+	users, err := fetchUsersFromUserService(userIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to fetch users: %v", err)})
+		return
+	}
+
+	// 4. レスポンス用のデータを構成
+	var result []AwsUserInfo
+	userMap := make(map[string]UserInfo)
+	for _, user := range users {
+		userMap[user.ID] = user
+	}
+
+	for _, rel := range relations {
+		if rel.UserID.Valid {
+			if user, exists := userMap[rel.UserID.String]; exists {
+				result = append(result, AwsUserInfo{
+					UserID:         user.ID,
+					UserName:       user.Name,
+					UserEmail:      user.Email,
+					AwsAccountID:   rel.AwsAccountID.String,
+					AwsAccountName: rel.Name,
+					Role:           rel.Role.String,
+				})
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// AWSアカウント削除API（実装例）
+func (h *handlers) deleteAwsAccount(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"message": "AWSアカウントが削除されました", "aws_account_id": c.Param("id")})
+}
+
+var errMemberExists = errors.New("ユーザーは既にメンバーです")
+
+// AWSアカウントメンバー追加API（user-serviceに存在するユーザーのみ追加できる）
+func (h *handlers) addAwsAccountMember(c *gin.Context) {
+	awsAccountID := c.Param("id")
+
+	var req AddAwsAccountMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.queries.GetAwsAccount(c, awsAccountID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "AWS account not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	exists, err := userExists(req.UserID)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("Failed to fetch users: %v", err)})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ユーザーが存在しません", "user_id": req.UserID})
+		return
+	}
+
+	// リレーションの追加と outbox への書き込みを1つのトランザクションで行う
+	var member sqlc.AwsAccountUserRelation
+	err = h.outbox.WriteMembership(c, func(tx pgx.Tx) (authz.MembershipChange, error) {
+		q := h.queries.WithTx(tx)
+		change := authz.MembershipChange{ResourceType: authz.ResourceAWS, ResourceID: awsAccountID, Subject: req.UserID, NewRole: req.Role}
+
+		var err error
+		member, err = q.AddAwsAccountMember(c, sqlc.AddAwsAccountMemberParams{
+			AwsAccountID: awsAccountID,
+			UserID:       req.UserID,
+			Role:         req.Role,
+		})
+		if err != nil {
+			// 23505: unique_violation（同じユーザーが既にメンバー）
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return change, errMemberExists
+			}
+			return change, err
+		}
+		return change, nil
+	})
+	if err != nil {
+		if errors.Is(err, errMemberExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "user_id": req.UserID})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, member)
+}
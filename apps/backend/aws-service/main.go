@@ -1,6 +1,8 @@
 package main
 
 import (
+	"authz"
+	"authz/authn"
 	"aws-service/db/sqlc"
 	"context"
 	"fmt"
@@ -28,8 +30,31 @@ func main() {
 	queries := sqlc.New(conn) // 生成されたクエリインターフェースを初期化
 	fmt.Println("データベースに正常に接続しました")
 
+	// 認証方式の設定
+	authenticator, err := authn.FromEnv()
+	if err != nil {
+		log.Fatalf("認証設定に失敗しました: %v", err)
+	}
+
+	// 認可エンジンの設定（判定結果はエンジン間で共有するキャッシュを通す）
+	cache, err := authz.DecisionCacheFromEnv()
+	if err != nil {
+		log.Fatalf("認可キャッシュの設定に失敗しました: %v", err)
+	}
+	authorizers, writers, err := authz.AuthorizersFromEnv(cache)
+	if err != nil {
+		log.Fatalf("認可エンジンの設定に失敗しました: %v", err)
+	}
+
+	// メンバー変更を outbox から各認可エンジンへ反映するワーカーを起動
+	outbox, err := authz.StartPostgresOutbox(context.Background(), conn, cache, writers...)
+	if err != nil {
+		log.Fatalf("outbox の設定に失敗しました: %v", err)
+	}
+
 	// ルーティング設定
-	r := setupRouter(queries)
+	base := handlers{queries: queries, cache: cache, outbox: outbox}
+	r := setupRouter(base, authenticator, authorizers)
 
 	port := os.Getenv("SERVER_PORT")
 	if port == "" {
@@ -46,4 +71,4 @@ func getDBURL() string {
 	dbname := os.Getenv("AWS_SERVICE_POSTGRES_DB")
 
 	return fmt.Sprintf("postgresql://%s:%s@%s:%s/%s", user, password, host, port, dbname)
-}
\ No newline at end of file
+}
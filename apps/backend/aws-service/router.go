@@ -2,7 +2,8 @@
 package main
 
 import (
-	"aws-service/db/sqlc"
+	"authz"
+	"authz/authn"
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -26,11 +27,12 @@ type BatchUsersRequest struct {
 }
 
 type AwsUserInfo struct {
-	UserID          string `json:"user_id"`
-	UserName        string `json:"user_name"`
-	UserEmail       string `json:"user_email"`
-	AwsAccountID    string `json:"aws_account_id"`
-	AwsAccountName  string `json:"aws_account_name"`
+	UserID         string `json:"user_id"`
+	UserName       string `json:"user_name"`
+	UserEmail      string `json:"user_email"`
+	AwsAccountID   string `json:"aws_account_id"`
+	AwsAccountName string `json:"aws_account_name"`
+	Role           string `json:"role"`
 }
 
 // AWSアカウント更新用のリクエスト構造体
@@ -39,6 +41,12 @@ type UpdateAwsAccountRequest struct {
 	Note string `json:"Note"`
 }
 
+// AWSアカウントメンバー追加用のリクエスト構造体
+type AddAwsAccountMemberRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+	Role   string `json:"role" binding:"required,oneof=owner manager staff"`
+}
+
 // User ServiceのURLを環境変数から取得（デフォルト値付き）
 var userServiceURL = func() string {
 	if url := os.Getenv("USER_SERVICE_URL"); url != "" {
@@ -47,7 +55,8 @@ var userServiceURL = func() string {
 	return "http://user-service:3003/api"
 }()
 
-func setupRouter(queries *sqlc.Queries) *gin.Engine {
+// setupRouter は base の依存関係を使い、認可エンジンごとのハンドラを登録する
+func setupRouter(base handlers, authenticator authn.Authenticator, authorizers []authz.Authorizer) *gin.Engine {
 	// Ginを設定
 	r := gin.Default()
 
@@ -55,7 +64,7 @@ func setupRouter(queries *sqlc.Queries) *gin.Engine {
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{
 		"http://localhost:3000", // Next.js development server
-		"http://localhost:3001", 
+		"http://localhost:3001",
 		"http://localhost:3002",
 	}
 	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
@@ -63,177 +72,85 @@ func setupRouter(queries *sqlc.Queries) *gin.Engine {
 	config.AllowCredentials = true
 	r.Use(cors.New(config))
 
-
 	// ヘルスチェック用の簡単なエンドポイントを定義
 	r.GET("/health", func(c *gin.Context) {
 		// 単純なレスポンスとしてステータス200を返す
-		c.JSON(http.StatusOK, gin.H{
-			"status": "UP",
-		})
-	})
-
-	setupCasbinRoutes(r.Group("/api/casbin"), queries)
-	setupOPARoutes(r.Group("/api/opa"), queries)
-	setupSpiceDBRoutes(r.Group("/api/spicedb"), queries)
-
-
-	return r
-}
-
-func setupRoutes(api *gin.RouterGroup, queries *sqlc.Queries) {
-
-	api.GET("/account/all", func(c *gin.Context) {
-		awsAccounts, err := queries.GetAwsAccounts(c)
+		lag, err := base.outbox.Lag(c)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "DOWN", "error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, awsAccounts)
-	})
-
-	api.GET("/account/:id", func(c *gin.Context) {
-		awsAccount, err := queries.GetAwsAccount(c, c.Param("id"))
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusOK, awsAccount)
-	})
-
-	// AWSアカウント更新エンドポイント
-	api.PUT("/account/:id", func(c *gin.Context) {
-		accountID := c.Param("id")
-		
-		var req UpdateAwsAccountRequest
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
-			return
-		}
-		
-		// アカウントの存在確認
-		_, err := queries.GetAwsAccount(c, accountID)
-		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "AWS account not found"})
-			return
-		}
-		
-		// アカウント情報を更新
-		updatedAccount, err := queries.UpdateAwsAccount(c, sqlc.UpdateAwsAccountParams{
-			ID:   accountID,
-			Name: req.Name,
-			Note: req.Note,
+		c.JSON(http.StatusOK, gin.H{
+			"status":       "UP",
+			"authz_cache":  base.cache.Stats(),
+			"authz_outbox": lag,
 		})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update account: " + err.Error()})
-			return
-		}
-		
-		c.JSON(http.StatusOK, updatedAccount)
 	})
 
-	api.GET("/account/system/:systemId", func(c *gin.Context) {
-		awsAccounts, err := queries.GetAwsAccountBySystemId(c, c.Param("systemId"))
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusOK, awsAccounts)
-	})
+	// /api 配下は認証必須
+	api := r.Group("/api", authn.Middleware(authenticator))
 
-	// AWSアカウントに所属するユーザー一覧を取得（ユーザー情報も含む）
-	api.GET("/account/:id/users", func(c *gin.Context) {
-		awsAccountID := c.Param("id")
-		
-		// 1. AWSアカウントに関連するユーザーIDを取得
-		relations, err := queries.GetAwsAccountUsersByAwsAccountId(c, awsAccountID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		
-		// 2. UserIDsを抽出（nullチェック付き）
-		var userIDs []string
-		for _, rel := range relations {
-			if rel.UserID.Valid {
-				userIDs = append(userIDs, rel.UserID.String)
-			}
-		}
-		
-		if len(userIDs) == 0 {
-			c.JSON(http.StatusOK, []AwsUserInfo{})
-			return
-		}
-		
-		// 3. User Serviceから一括でユーザー情報を取得
-		users, err := fetchUsersFromUserService(userIDs)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to fetch users: %v", err)})
-			return
-		}
-		
-		// 4. レスポンス用のデータを構成
-		var result []AwsUserInfo
-		userMap := make(map[string]UserInfo)
-		for _, user := range users {
-			userMap[user.ID] = user
-		}
-		
-		for _, rel := range relations {
-			if rel.UserID.Valid {
-				if user, exists := userMap[rel.UserID.String]; exists {
-					result = append(result, AwsUserInfo{
-						UserID:         user.ID,
-						UserName:       user.Name,
-						UserEmail:      user.Email,
-						AwsAccountID:   rel.ID,
-						AwsAccountName: rel.Name,
-					})
-				}
-			}
-		}
-		
-		c.JSON(http.StatusOK, result)
-	})
+	// 認可エンジンごとに同じルートを /api/<engine> にマウント
+	for _, authorizer := range authorizers {
+		h := base
+		h.authorizer = authorizer
+		authz.NewGuard(authorizer, authn.SubjectFrom).Mount(api.Group("/"+authorizer.Name()), h.routes())
+	}
 
+	return r
 }
 
 // User Serviceからユーザー情報を一括取得する関数
 func fetchUsersFromUserService(userIDs []string) ([]UserInfo, error) {
 	// User ServiceのURL
 	url := userServiceURL + "/users/batch"
-	
+
 	// リクエストボディを作成
 	requestBody := BatchUsersRequest{
 		UserIDs: userIDs,
 	}
-	
+
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
-	
+
 	// HTTP POSTリクエストを送信
 	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to call user service: %w", err)
 	}
 	defer resp.Body.Close()
-	
+
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("user service returned status: %d", resp.StatusCode)
 	}
-	
+
 	// レスポンスを読み取り
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
-	
+
 	// JSONをパース
 	var users []UserInfo
 	if err := json.Unmarshal(body, &users); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
-	
+
 	return users, nil
-}
\ No newline at end of file
+}
+
+// ユーザーがUser Serviceに存在するかを確認する関数
+func userExists(userID string) (bool, error) {
+	users, err := fetchUsersFromUserService([]string{userID})
+	if err != nil {
+		return false, err
+	}
+	for _, user := range users {
+		if user.ID == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"authz"
+	"net/http"
+)
+
+// routes はAWSアカウント関連APIのルート表。どの認可エンジンでも同じ表を使う
+func (h *handlers) routes() []authz.Route {
+	return []authz.Route{
+		{Method: http.MethodGet, Path: "/account/all", Handler: h.listAwsAccounts},
+		{Method: http.MethodGet, Path: "/account/:id", ResourceType: authz.ResourceAWS, Param: "id", Permission: authz.PermissionRead, Handler: h.getAwsAccount},
+		{Method: http.MethodPut, Path: "/account/:id", ResourceType: authz.ResourceAWS, Param: "id", Permission: authz.PermissionWrite, Handler: h.updateAwsAccount},
+		{Method: http.MethodGet, Path: "/account/system/:systemId", ResourceType: authz.ResourceSystem, Param: "systemId", Permission: authz.PermissionRead, Handler: h.getAwsAccountsBySystem},
+		{Method: http.MethodGet, Path: "/account/:id/users", ResourceType: authz.ResourceAWS, Param: "id", Permission: authz.PermissionRead, Handler: h.getAwsAccountUsers},
+		{Method: http.MethodDelete, Path: "/account/:id", ResourceType: authz.ResourceAWS, Param: "id", Permission: authz.PermissionDelete, Handler: h.deleteAwsAccount},
+		{Method: http.MethodPost, Path: "/account/:id/members", ResourceType: authz.ResourceAWS, Param: "id", Permission: authz.PermissionManageMembers, Handler: h.addAwsAccountMember},
+	}
+}
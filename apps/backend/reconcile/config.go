@@ -0,0 +1,55 @@
+package main
+
+import (
+	"authz"
+	"fmt"
+	"os"
+)
+
+// engine はロール割り当ての読み出しと書き込みができる認可エンジン
+type engine interface {
+	authz.MembershipReader
+	authz.MembershipWriter
+}
+
+// newEngines は名前からエンジンを構築する。URL・認証キー・mTLS の設定は system-service / aws-service と同じ環境変数を使う
+func newEngines(names []string) ([]engine, error) {
+	opts, err := authz.ClientOptionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	engines := make([]engine, 0, len(names))
+	for _, name := range names {
+		switch name {
+		case "casbin":
+			casbin, err := authz.CasbinAuthorizerFromEnv(opts...)
+			if err != nil {
+				return nil, err
+			}
+			engines = append(engines, casbin)
+		case "opa":
+			opa, err := authz.OPAAuthorizerFromEnv(opts...)
+			if err != nil {
+				return nil, err
+			}
+			engines = append(engines, opa)
+		case "spicedb":
+			engines = append(engines, authz.SpiceDBAuthorizerFromEnv())
+		default:
+			return nil, fmt.Errorf("unknown engine: %s", name)
+		}
+	}
+	return engines, nil
+}
+
+// getDBURL は prefix（SYSTEM_SERVICE / AWS_SERVICE）の接続情報から接続URLを組み立てる
+func getDBURL(prefix string) string {
+	host := os.Getenv(prefix + "_POSTGRES_HOST")
+	port := os.Getenv(prefix + "_POSTGRES_PORT")
+	user := os.Getenv(prefix + "_POSTGRES_USER")
+	password := os.Getenv(prefix + "_POSTGRES_PASSWORD")
+	dbname := os.Getenv(prefix + "_POSTGRES_DB")
+
+	return fmt.Sprintf("postgresql://%s:%s@%s:%s/%s", user, password, host, port, dbname)
+}
@@ -0,0 +1,135 @@
+// reconcile はサービスDBのメンバー（system_user_relation / aws_account_user_relation）と
+// 各認可エンジン（Casbin / OPA / SpiceDB）のロール割り当てを比較し、差分を報告する。
+// -repair を指定した場合は DB に合わせてエンジン側を修正する
+package main
+
+import (
+	"authz"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// engineReport は1エンジン分の比較結果
+type engineReport struct {
+	Engine   string             `json:"engine"`
+	Missing  []authz.Membership `json:"missing"` // DB にあってエンジンにない割り当て
+	Extra    []authz.Membership `json:"extra"`   // エンジンにだけある割り当て
+	Repaired bool               `json:"repaired,omitempty"`
+	Error    string             `json:"error,omitempty"`
+}
+
+func (r engineReport) drifted() bool {
+	return len(r.Missing) > 0 || len(r.Extra) > 0
+}
+
+func main() {
+	enginesFlag := flag.String("engines", "casbin,opa,spicedb", "比較する認可エンジン（カンマ区切り）")
+	repair := flag.Bool("repair", false, "差分をエンジン側に反映して DB に合わせる")
+	jsonOutput := flag.Bool("json", false, "結果を JSON で出力する")
+	timeout := flag.Duration("timeout", time.Minute, "全体のタイムアウト")
+	flag.Parse()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	engines, err := newEngines(strings.Split(*enginesFlag, ","))
+	if err != nil {
+		log.Fatalf("認可エンジンの設定に失敗しました: %v", err)
+	}
+
+	want, err := loadMemberships(ctx)
+	if err != nil {
+		log.Fatalf("DB からメンバーを取得できません: %v", err)
+	}
+
+	reports := make([]engineReport, len(engines))
+	for i, e := range engines {
+		reports[i] = reconcile(ctx, e, want, *repair)
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(reports)
+	} else {
+		printReports(reports)
+	}
+
+	// 終了コード: 1 = エラーあり、2 = 差分が残っている
+	code := 0
+	for _, r := range reports {
+		switch {
+		case r.Error != "":
+			code = 1
+		case r.drifted() && !r.Repaired && code == 0:
+			code = 2
+		}
+	}
+	os.Exit(code)
+}
+
+// reconcile はエンジンの割り当てを DB と比較し、repair の場合は余分な割り当てを削除してから不足分を追加する
+func reconcile(ctx context.Context, e engine, want []authz.Membership, repair bool) engineReport {
+	report := engineReport{Engine: e.Name()}
+
+	got, err := e.ListMemberships(ctx)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	report.Missing, report.Extra = authz.DiffMemberships(want, got)
+	if !repair || !report.drifted() {
+		return report
+	}
+
+	for _, m := range report.Extra {
+		change := authz.MembershipChange{ResourceType: m.ResourceType, ResourceID: m.ResourceID, Subject: m.Subject, OldRole: m.Role}
+		if err := e.ApplyMembership(ctx, change); err != nil {
+			report.Error = fmt.Sprintf("failed to remove %s: %v", m, err)
+			return report
+		}
+	}
+	for _, m := range report.Missing {
+		change := authz.MembershipChange{ResourceType: m.ResourceType, ResourceID: m.ResourceID, Subject: m.Subject, NewRole: m.Role}
+		if err := e.ApplyMembership(ctx, change); err != nil {
+			report.Error = fmt.Sprintf("failed to add %s: %v", m, err)
+			return report
+		}
+	}
+	report.Repaired = true
+	return report
+}
+
+func printReports(reports []engineReport) {
+	for _, r := range reports {
+		switch {
+		case r.Error != "" && !r.drifted():
+			fmt.Printf("== %s: error: %s\n", r.Engine, r.Error)
+			continue
+		case !r.drifted():
+			fmt.Printf("== %s: ok\n", r.Engine)
+			continue
+		}
+
+		status := "drift"
+		if r.Repaired {
+			status = "repaired"
+		}
+		fmt.Printf("== %s: %s (%d missing, %d extra)\n", r.Engine, status, len(r.Missing), len(r.Extra))
+		for _, m := range r.Missing {
+			fmt.Printf("  missing  %s\n", m)
+		}
+		for _, m := range r.Extra {
+			fmt.Printf("  extra    %s\n", m)
+		}
+		if r.Error != "" {
+			fmt.Printf("  error: %s\n", r.Error)
+		}
+	}
+}
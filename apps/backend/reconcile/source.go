@@ -0,0 +1,47 @@
+package main
+
+import (
+	"authz"
+	awssqlc "aws-service/db/sqlc"
+	"context"
+	"fmt"
+	systemsqlc "system-service/db/sqlc"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// loadMemberships は system_user_relation と aws_account_user_relation からロール割り当てを読み出す。
+// DB が正となる
+func loadMemberships(ctx context.Context) ([]authz.Membership, error) {
+	var memberships []authz.Membership
+
+	systemConn, err := pgx.Connect(ctx, getDBURL("SYSTEM_SERVICE"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to system database: %w", err)
+	}
+	defer systemConn.Close(ctx)
+
+	systemMembers, err := systemsqlc.New(systemConn).ListSystemMembers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list system members: %w", err)
+	}
+	for _, m := range systemMembers {
+		memberships = append(memberships, authz.Membership{ResourceType: authz.ResourceSystem, ResourceID: m.SystemID, Subject: m.UserID, Role: m.Role})
+	}
+
+	awsConn, err := pgx.Connect(ctx, getDBURL("AWS_SERVICE"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to aws database: %w", err)
+	}
+	defer awsConn.Close(ctx)
+
+	awsMembers, err := awssqlc.New(awsConn).ListAwsAccountMembers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list aws account members: %w", err)
+	}
+	for _, m := range awsMembers {
+		memberships = append(memberships, authz.Membership{ResourceType: authz.ResourceAWS, ResourceID: m.AwsAccountID, Subject: m.UserID, Role: m.Role})
+	}
+
+	return memberships, nil
+}
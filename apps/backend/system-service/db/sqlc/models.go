@@ -14,4 +14,5 @@ type SystemUserRelation struct {
 	ID       string
 	SystemID string
 	UserID   string
+	Role     string
 }
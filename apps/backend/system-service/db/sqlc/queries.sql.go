@@ -11,6 +11,47 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addSystemMember = `-- name: AddSystemMember :one
+INSERT INTO system_user_relation (id, system_id, user_id, role)
+VALUES (gen_random_uuid()::text, $1, $2, $3)
+RETURNING id, system_id, user_id, role
+`
+
+type AddSystemMemberParams struct {
+	SystemID string
+	UserID   string
+	Role     string
+}
+
+func (q *Queries) AddSystemMember(ctx context.Context, arg AddSystemMemberParams) (SystemUserRelation, error) {
+	row := q.db.QueryRow(ctx, addSystemMember, arg.SystemID, arg.UserID, arg.Role)
+	var i SystemUserRelation
+	err := row.Scan(
+		&i.ID,
+		&i.SystemID,
+		&i.UserID,
+		&i.Role,
+	)
+	return i, err
+}
+
+const deleteSystemMember = `-- name: DeleteSystemMember :execrows
+DELETE FROM system_user_relation WHERE system_id = $1 AND user_id = $2
+`
+
+type DeleteSystemMemberParams struct {
+	SystemID string
+	UserID   string
+}
+
+func (q *Queries) DeleteSystemMember(ctx context.Context, arg DeleteSystemMemberParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSystemMember, arg.SystemID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getSystem = `-- name: GetSystem :one
 SELECT id, name, note FROM system WHERE id = $1
 `
@@ -23,7 +64,7 @@ func (q *Queries) GetSystem(ctx context.Context, id string) (System, error) {
 }
 
 const getSystemAccounts = `-- name: GetSystemAccounts :many
-SELECT t1.id, t1.name, t1.note, t2.id, t2.system_id, t2.user_id 
+SELECT t1.id, t1.name, t1.note, t2.id, t2.system_id, t2.user_id, t2.role 
 FROM system t1 
 LEFT JOIN system_user_relation t2 ON t1.id = t2.system_id 
 WHERE t1.id = $1
@@ -36,6 +77,7 @@ type GetSystemAccountsRow struct {
 	ID_2     pgtype.Text
 	SystemID pgtype.Text
 	UserID   pgtype.Text
+	Role     pgtype.Text
 }
 
 func (q *Queries) GetSystemAccounts(ctx context.Context, id string) ([]GetSystemAccountsRow, error) {
@@ -54,6 +96,7 @@ func (q *Queries) GetSystemAccounts(ctx context.Context, id string) ([]GetSystem
 			&i.ID_2,
 			&i.SystemID,
 			&i.UserID,
+			&i.Role,
 		); err != nil {
 			return nil, err
 		}
@@ -65,6 +108,27 @@ func (q *Queries) GetSystemAccounts(ctx context.Context, id string) ([]GetSystem
 	return items, nil
 }
 
+const getSystemMemberForUpdate = `-- name: GetSystemMemberForUpdate :one
+SELECT id, system_id, user_id, role FROM system_user_relation WHERE system_id = $1 AND user_id = $2 FOR UPDATE
+`
+
+type GetSystemMemberForUpdateParams struct {
+	SystemID string
+	UserID   string
+}
+
+func (q *Queries) GetSystemMemberForUpdate(ctx context.Context, arg GetSystemMemberForUpdateParams) (SystemUserRelation, error) {
+	row := q.db.QueryRow(ctx, getSystemMemberForUpdate, arg.SystemID, arg.UserID)
+	var i SystemUserRelation
+	err := row.Scan(
+		&i.ID,
+		&i.SystemID,
+		&i.UserID,
+		&i.Role,
+	)
+	return i, err
+}
+
 const getSystems = `-- name: GetSystems :many
 SELECT id, name, note FROM system
 `
@@ -89,6 +153,59 @@ func (q *Queries) GetSystems(ctx context.Context) ([]System, error) {
 	return items, nil
 }
 
+const getSystemsByIDs = `-- name: GetSystemsByIDs :many
+SELECT id, name, note FROM system WHERE id = ANY($1::text[])
+`
+
+func (q *Queries) GetSystemsByIDs(ctx context.Context, ids []string) ([]System, error) {
+	rows, err := q.db.Query(ctx, getSystemsByIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []System
+	for rows.Next() {
+		var i System
+		if err := rows.Scan(&i.ID, &i.Name, &i.Note); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSystemMembers = `-- name: ListSystemMembers :many
+SELECT id, system_id, user_id, role FROM system_user_relation ORDER BY system_id, user_id
+`
+
+func (q *Queries) ListSystemMembers(ctx context.Context) ([]SystemUserRelation, error) {
+	rows, err := q.db.Query(ctx, listSystemMembers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SystemUserRelation
+	for rows.Next() {
+		var i SystemUserRelation
+		if err := rows.Scan(
+			&i.ID,
+			&i.SystemID,
+			&i.UserID,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateSystem = `-- name: UpdateSystem :one
 UPDATE system 
 SET name = $2, note = $3 
@@ -108,3 +225,28 @@ func (q *Queries) UpdateSystem(ctx context.Context, arg UpdateSystemParams) (Sys
 	err := row.Scan(&i.ID, &i.Name, &i.Note)
 	return i, err
 }
+
+const updateSystemMemberRole = `-- name: UpdateSystemMemberRole :one
+UPDATE system_user_relation
+SET role = $3
+WHERE system_id = $1 AND user_id = $2
+RETURNING id, system_id, user_id, role
+`
+
+type UpdateSystemMemberRoleParams struct {
+	SystemID string
+	UserID   string
+	Role     string
+}
+
+func (q *Queries) UpdateSystemMemberRole(ctx context.Context, arg UpdateSystemMemberRoleParams) (SystemUserRelation, error) {
+	row := q.db.QueryRow(ctx, updateSystemMemberRole, arg.SystemID, arg.UserID, arg.Role)
+	var i SystemUserRelation
+	err := row.Scan(
+		&i.ID,
+		&i.SystemID,
+		&i.UserID,
+		&i.Role,
+	)
+	return i, err
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"authz"
+	"errors"
+	"fmt"
+	"net/http"
+	"system-service/db/sqlc"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// handlers はシステム関連APIのハンドラ。認可チェックはルート表のミドルウェアで行う
+type handlers struct {
+	queries    *sqlc.Queries
+	authorizer authz.Authorizer
+	cache      *authz.DecisionCache  // /health で統計を返す
+	outbox     *authz.PostgresOutbox // メンバー変更を書き込み、全認可エンジンへ反映する
+}
+
+// システム一覧を取得するAPI（読み取り権限のあるシステムのみ）
+func (h *handlers) listSystems(c *gin.Context) {
+	subject := authz.SubjectFrom(c)
+
+	// LookupResources に対応したエンジン（SpiceDB）は、読み取り可能なIDのみをDBから取得する
+	set, err := h.authorizer.LookupResources(c, subject, authz.ResourceSystem, authz.PermissionRead)
+	if err == nil {
+		var items []sqlc.System
+		if set.All {
+			items, err = h.queries.GetSystems(c)
+		} else {
+			items, err = h.queries.GetSystemsByIDs(c, set.IDs)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if items == nil {
+			items = []sqlc.System{}
+		}
+		c.JSON(http.StatusOK, items)
+		return
+	}
+	if !errors.Is(err, authz.ErrNotSupported) {
+		c.JSON(http.StatusInternalServerError, authz.ErrorResponse{Error: fmt.Sprintf("認可チェックエラー: %v", err), Code: "authorization_error"})
+		return
+	}
+
+	// それ以外のエンジンは全システムを取得して一括チェックで絞り込む
+	allSystems, err := h.queries.GetSystems(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 読み取り権限のあるシステムのみフィルタリング
+	ids := make([]string, len(allSystems))
+	for i, system := range allSystems {
+		ids[i] = system.ID
+	}
+	allowedIDs, err := authz.FilterAllowed(c, h.authorizer, subject, authz.ResourceSystem, authz.PermissionRead, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, authz.ErrorResponse{Error: fmt.Sprintf("認可チェックエラー: %v", err), Code: "authorization_error"})
+		return
+	}
+	allowed := make(map[string]bool, len(allowedIDs))
+	for _, id := range allowedIDs {
+		allowed[id] = true
+	}
+
+	// 空のスライスがnullになるのを防ぐため、明示的に空配列を初期化
+	accessibleSystems := []sqlc.System{}
+	for _, system := range allSystems {
+		if allowed[system.ID] {
+			accessibleSystems = append(accessibleSystems, system)
+		}
+	}
+
+	c.JSON(http.StatusOK, accessibleSystems)
+}
+
+// システム詳細を取得するAPI
+func (h *handlers) getSystem(c *gin.Context) {
+	system, err := h.queries.GetSystem(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, system)
+}
+
+// システムアカウント情報を取得するAPI
+func (h *handlers) getSystemAccounts(c *gin.Context) {
+	accounts, err := h.queries.GetSystemAccounts(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, accounts)
+}
+
+// 🎯 システムに所属するユーザの名称一覧を取得するAPI
+func (h *handlers) getSystemUsers(c *gin.Context) {
+	systemID := c.Param("id")
+
+	// 1. システムに関連するユーザーIDを取得
+	relations, err := h.queries.GetSystemAccounts(c, systemID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 2. UserIDsを抽出（nullチェック付き）
+	var userIDs []string
+	for _, rel := range relations {
+		if rel.UserID.Valid {
+			userIDs = append(userIDs, rel.UserID.String)
+		}
+	}
+
+	if len(userIDs) == 0 {
+		c.JSON(http.StatusOK, []SystemUserInfo{})
+		return
+	}
+
+	// 3. User Serviceから一括でユーザー情報を取得
+	users, err := fetchUsersFromUserService(userIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to fetch users: %v", err)})
+		return
+	}
+
+	// 4. レスポンス用のデータを構成
+	var result []SystemUserInfo
+	userMap := make(map[string]UserInfo)
+	for _, user := range users {
+		userMap[user.ID] = user
+	}
+
+	for _, rel := range relations {
+		if rel.UserID.Valid {
+			if user, exists := userMap[rel.UserID.String]; exists {
+				result = append(result, SystemUserInfo{
+					UserID:    user.ID,
+					UserName:  user.Name,
+					UserEmail: user.Email,
+					SystemID:  systemID,
+					Role:      rel.Role.String,
+				})
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// システム更新API
+func (h *handlers) updateSystem(c *gin.Context) {
+	var req UpdateSystemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// システムを更新
+	updatedSystem, err := h.queries.UpdateSystem(c, sqlc.UpdateSystemParams{
+		ID:   c.Param("id"),
+		Name: req.Name,
+		Note: req.Note,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedSystem)
+}
+
+// システム削除API（実装例 - 実際のDeleteSystem関数が必要）
+func (h *handlers) deleteSystem(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"message": "システムが削除されました", "system_id": c.Param("id")})
+}
+
+// メンバー変更時のDBエラー
+var (
+	errMemberExists   = errors.New("ユーザーは既にメンバーです")
+	errMemberNotFound = errors.New("メンバーが見つかりません")
+)
+
+// メンバー追加API（user-serviceに存在するユーザーのみ追加できる）
+func (h *handlers) addSystemMember(c *gin.Context) {
+	systemID := c.Param("id")
+
+	var req AddSystemMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.queries.GetSystem(c, systemID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "システムが見つかりません"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	exists, err := userExists(req.UserID)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("Failed to fetch users: %v", err)})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ユーザーが存在しません", "user_id": req.UserID})
+		return
+	}
+
+	// リレーションの追加と outbox への書き込みを1つのトランザクションで行う
+	var member sqlc.SystemUserRelation
+	err = h.outbox.WriteMembership(c, func(tx pgx.Tx) (authz.MembershipChange, error) {
+		q := h.queries.WithTx(tx)
+		change := authz.MembershipChange{ResourceType: authz.ResourceSystem, ResourceID: systemID, Subject: req.UserID, NewRole: req.Role}
+
+		var err error
+		member, err = q.AddSystemMember(c, sqlc.AddSystemMemberParams{
+			SystemID: systemID,
+			UserID:   req.UserID,
+			Role:     req.Role,
+		})
+		if err != nil {
+			// 23505: unique_violation（同じユーザーが既にメンバー）
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return change, errMemberExists
+			}
+			return change, err
+		}
+		return change, nil
+	})
+	if err != nil {
+		respondMembershipError(c, err, req.UserID)
+		return
+	}
+
+	c.JSON(http.StatusCreated, member)
+}
+
+// メンバーのロール変更API
+func (h *handlers) updateSystemMemberRole(c *gin.Context) {
+	systemID := c.Param("id")
+	userID := c.Param("userId")
+
+	var req UpdateSystemMemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var member sqlc.SystemUserRelation
+	err := h.outbox.WriteMembership(c, func(tx pgx.Tx) (authz.MembershipChange, error) {
+		q := h.queries.WithTx(tx)
+		change := authz.MembershipChange{ResourceType: authz.ResourceSystem, ResourceID: systemID, Subject: userID, NewRole: req.Role}
+
+		// 変更前のロールを行ロック付きで取得する
+		current, err := q.GetSystemMemberForUpdate(c, sqlc.GetSystemMemberForUpdateParams{SystemID: systemID, UserID: userID})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return change, errMemberNotFound
+			}
+			return change, err
+		}
+		change.OldRole = current.Role
+
+		member, err = q.UpdateSystemMemberRole(c, sqlc.UpdateSystemMemberRoleParams{
+			SystemID: systemID,
+			UserID:   userID,
+			Role:     req.Role,
+		})
+		return change, err
+	})
+	if err != nil {
+		respondMembershipError(c, err, userID)
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// メンバー削除API
+func (h *handlers) removeSystemMember(c *gin.Context) {
+	systemID := c.Param("id")
+	userID := c.Param("userId")
+
+	err := h.outbox.WriteMembership(c, func(tx pgx.Tx) (authz.MembershipChange, error) {
+		q := h.queries.WithTx(tx)
+		change := authz.MembershipChange{ResourceType: authz.ResourceSystem, ResourceID: systemID, Subject: userID}
+
+		current, err := q.GetSystemMemberForUpdate(c, sqlc.GetSystemMemberForUpdateParams{SystemID: systemID, UserID: userID})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return change, errMemberNotFound
+			}
+			return change, err
+		}
+		change.OldRole = current.Role
+
+		_, err = q.DeleteSystemMember(c, sqlc.DeleteSystemMemberParams{SystemID: systemID, UserID: userID})
+		return change, err
+	})
+	if err != nil {
+		respondMembershipError(c, err, userID)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "メンバーが削除されました", "system_id": systemID, "user_id": userID})
+}
+
+// respondMembershipError はメンバー変更のエラーをステータスコードに対応付けて返す
+func respondMembershipError(c *gin.Context, err error, userID string) {
+	switch {
+	case errors.Is(err, errMemberExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "user_id": userID})
+	case errors.Is(err, errMemberNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "user_id": userID})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
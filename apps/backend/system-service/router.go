@@ -2,15 +2,16 @@
 package main
 
 import (
-	"fmt"
+	"authz"
+	"authz/authn"
 	"net/http"
-	"system-service/db/sqlc"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
-func setupRouter(queries *sqlc.Queries) *gin.Engine {
+// setupRouter は base の依存関係を使い、認可エンジンごとのハンドラを登録する
+func setupRouter(base handlers, authenticator authn.Authenticator, authorizers []authz.Authorizer) *gin.Engine {
 	// Ginを設定
 	r := gin.Default()
 
@@ -18,7 +19,7 @@ func setupRouter(queries *sqlc.Queries) *gin.Engine {
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{
 		"http://localhost:3000", // Next.js development server
-		"http://localhost:3001", 
+		"http://localhost:3001",
 		"http://localhost:3002",
 	}
 	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
@@ -29,122 +30,27 @@ func setupRouter(queries *sqlc.Queries) *gin.Engine {
 	// ヘルスチェック用の簡単なエンドポイントを定義
 	r.GET("/health", func(c *gin.Context) {
 		// 単純なレスポンスとしてステータス200を返す
-		c.JSON(http.StatusOK, gin.H{
-			"status": "UP",
-		})
-	})
-	setupCasbinRoutes(r.Group("/api/casbin"), queries)
-	setupOPARoutes(r.Group("/api/opa"), queries)
-	setupSpiceDBRoutes(r.Group("/api/spicedb"), queries)
-
-	return r
-}
-
-
-func  setupRoutes(api *gin.RouterGroup,queries *sqlc.Queries) {
-
-	api.GET("/system/all", func(c *gin.Context) {
-		systems, err := queries.GetSystems(c)
+		lag, err := base.outbox.Lag(c)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "DOWN", "error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, systems)
+		c.JSON(http.StatusOK, gin.H{
+			"status":       "UP",
+			"authz_cache":  base.cache.Stats(),
+			"authz_outbox": lag,
+		})
 	})
 
-	api.GET("/system/:id", func(c *gin.Context) {
-		system, err := queries.GetSystem(c, c.Param("id"))
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusOK, system)
-	})	
-
-	api.GET("/system/account/:id", func(c *gin.Context) {
-		accounts, err := queries.GetSystemAccounts(c, c.Param("id"))
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusOK, accounts)
-	})
+	// /api 配下は認証必須
+	api := r.Group("/api", authn.Middleware(authenticator))
 
-	// 🎯 システムに所属するユーザの名称一覧を取得するAPI
-	api.GET("/system/:id/users", func(c *gin.Context) {
-		systemID := c.Param("id")
-		
-		// 1. システムに関連するユーザーIDを取得
-		relations, err := queries.GetSystemAccounts(c, systemID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		
-		// 2. UserIDsを抽出（nullチェック付き）
-		var userIDs []string
-		for _, rel := range relations {
-			if rel.UserID.Valid {
-				userIDs = append(userIDs, rel.UserID.String)
-			}
-		}
-		
-		if len(userIDs) == 0 {
-			c.JSON(http.StatusOK, []SystemUserInfo{})
-			return
-		}
-		
-		// 3. User Serviceから一括でユーザー情報を取得
-		users, err := fetchUsersFromUserService(userIDs)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to fetch users: %v", err)})
-			return
-		}
-		
-		// 4. レスポンス用のデータを構成
-		var result []SystemUserInfo
-		userMap := make(map[string]UserInfo)
-		for _, user := range users {
-			userMap[user.ID] = user
-		}
-		
-		for _, rel := range relations {
-			if rel.UserID.Valid {
-				if user, exists := userMap[rel.UserID.String]; exists {
-					result = append(result, SystemUserInfo{
-						UserID:    user.ID,
-						UserName:  user.Name,
-						UserEmail: user.Email,
-						SystemID:  systemID,
-					})
-				}
-			}
-		}
-		
-		c.JSON(http.StatusOK, result)
-	})
+	// 認可エンジンごとに同じルートを /api/<engine> にマウント
+	for _, authorizer := range authorizers {
+		h := base
+		h.authorizer = authorizer
+		authz.NewGuard(authorizer, authn.SubjectFrom).Mount(api.Group("/"+authorizer.Name()), h.routes())
+	}
 
-	// システム更新API
-	api.PUT("/system/:id", func(c *gin.Context) {
-		systemID := c.Param("id")
-		
-		var req UpdateSystemRequest
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		
-		// システムを更新
-		updatedSystem, err := queries.UpdateSystem(c, sqlc.UpdateSystemParams{
-			ID:   systemID,
-			Name: req.Name,
-			Note: req.Note,
-		})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		
-		c.JSON(http.StatusOK, updatedSystem)
-	})
-}
\ No newline at end of file
+	return r
+}
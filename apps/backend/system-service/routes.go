@@ -0,0 +1,21 @@
+package main
+
+import (
+	"authz"
+	"net/http"
+)
+
+// routes はシステム関連APIのルート表。どの認可エンジンでも同じ表を使う
+func (h *handlers) routes() []authz.Route {
+	return []authz.Route{
+		{Method: http.MethodGet, Path: "/system/all", Handler: h.listSystems},
+		{Method: http.MethodGet, Path: "/system/:id", ResourceType: authz.ResourceSystem, Param: "id", Permission: authz.PermissionRead, Handler: h.getSystem},
+		{Method: http.MethodGet, Path: "/system/account/:id", ResourceType: authz.ResourceSystem, Param: "id", Permission: authz.PermissionRead, Handler: h.getSystemAccounts},
+		{Method: http.MethodGet, Path: "/system/:id/users", ResourceType: authz.ResourceSystem, Param: "id", Permission: authz.PermissionRead, Handler: h.getSystemUsers},
+		{Method: http.MethodPut, Path: "/system/:id", ResourceType: authz.ResourceSystem, Param: "id", Permission: authz.PermissionWrite, Handler: h.updateSystem},
+		{Method: http.MethodDelete, Path: "/system/:id", ResourceType: authz.ResourceSystem, Param: "id", Permission: authz.PermissionDelete, Handler: h.deleteSystem},
+		{Method: http.MethodPost, Path: "/system/:id/members", ResourceType: authz.ResourceSystem, Param: "id", Permission: authz.PermissionManageMembers, Handler: h.addSystemMember},
+		{Method: http.MethodPut, Path: "/system/:id/members/:userId", ResourceType: authz.ResourceSystem, Param: "id", Permission: authz.PermissionManageMembers, Handler: h.updateSystemMemberRole},
+		{Method: http.MethodDelete, Path: "/system/:id/members/:userId", ResourceType: authz.ResourceSystem, Param: "id", Permission: authz.PermissionManageMembers, Handler: h.removeSystemMember},
+	}
+}
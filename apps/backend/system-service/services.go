@@ -56,4 +56,18 @@ func fetchUsersFromUserService(userIDs []string) ([]UserInfo, error) {
 	}
 	
 	return users, nil
-} 
\ No newline at end of file
+}
+
+// User Serviceにユーザーが存在するかを確認する関数
+func userExists(userID string) (bool, error) {
+	users, err := fetchUsersFromUserService([]string{userID})
+	if err != nil {
+		return false, err
+	}
+	for _, user := range users {
+		if user.ID == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
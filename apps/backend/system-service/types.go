@@ -16,10 +16,22 @@ type SystemUserInfo struct {
 	UserName  string `json:"user_name"`
 	UserEmail string `json:"user_email"`
 	SystemID  string `json:"system_id"`
+	Role      string `json:"role,omitempty"`
 }
 
 // システム更新用のリクエスト構造体
 type UpdateSystemRequest struct {
 	Name string `json:"Name" binding:"required"`
 	Note string `json:"Note"`
-} 
\ No newline at end of file
+}
+
+// メンバー追加用のリクエスト構造体
+type AddSystemMemberRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+	Role   string `json:"role" binding:"required,oneof=owner manager staff"`
+}
+
+// メンバーのロール変更用のリクエスト構造体
+type UpdateSystemMemberRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=owner manager staff"`
+}
@@ -1,5 +1,8 @@
 import { NextApiRequest, NextApiResponse } from "next";
 
+// Casbinサーバのサービス間認証キー（既定値は持たず、未設定の場合は 500 を返す）
+const CASBIN_AUTH_KEY = process.env.CASBIN_AUTH_KEY;
+
 // Docker環境でのCasbinサービスURL
 const CASBIN_SERVICE_URL =
   process.env.CASBIN_SERVICE_URL || "http://casbin-server:8080";
@@ -12,11 +15,17 @@ export default async function handler(
     return res.status(405).json({ error: "Method not allowed" });
   }
 
+  if (!CASBIN_AUTH_KEY) {
+    console.error("CASBIN_AUTH_KEY is not set");
+    return res.status(500).json({ error: "CASBIN_AUTH_KEY is not configured" });
+  }
+
   try {
     const response = await fetch(`${CASBIN_SERVICE_URL}/authorize`, {
       method: "POST",
       headers: {
         "Content-Type": "application/json",
+        Authorization: `Bearer ${CASBIN_AUTH_KEY}`,
       },
       body: JSON.stringify(req.body),
     });
@@ -1,5 +1,8 @@
 import type { NextApiRequest, NextApiResponse } from "next";
 
+// OPAサーバのサービス間認証キー（既定値は持たず、未設定の場合は 500 を返す）
+const OPA_AUTH_KEY = process.env.OPA_AUTH_KEY;
+
 const OPA_SERVICE_URL = process.env.OPA_SERVICE_URL || "http://opa-server:8081";
 
 export default async function handler(
@@ -10,11 +13,17 @@ export default async function handler(
     return res.status(405).json({ error: "Method not allowed" });
   }
 
+  if (!OPA_AUTH_KEY) {
+    console.error("OPA_AUTH_KEY is not set");
+    return res.status(500).json({ error: "OPA_AUTH_KEY is not configured" });
+  }
+
   try {
     const response = await fetch(`${OPA_SERVICE_URL}/authorize`, {
       method: "POST",
       headers: {
         "Content-Type": "application/json",
+        Authorization: `Bearer ${OPA_AUTH_KEY}`,
       },
       body: JSON.stringify(req.body),
     });
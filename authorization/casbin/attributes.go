@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/util"
+	"github.com/casbin/govaluate"
+)
+
+// p 行の cond の既定値（属性の条件なし）
+const conditionAlways = "true"
+
+// 属性マップのキー
+const (
+	attrTime = "time" // RFC3339 の日時。省略時はリクエストを受けた時刻
+	attrIP   = "ip"   // クライアントの IP アドレス
+	attrTags = "tags" // リソースのタグ（例: {"env": "production"}）
+)
+
+// requestAttributes は AuthRequest の属性マップをマッチャーに渡す r.attrs にする
+func requestAttributes(attrs map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for k, v := range attrs {
+		result[k] = v
+	}
+	if _, ok := result[attrTime]; !ok {
+		result[attrTime] = time.Now().Format(time.RFC3339)
+	}
+	return result
+}
+
+// conditionFunctions は p 行の cond で使う関数。
+// いずれも属性がない・不正な場合は false を返す（条件付きの許可は与えない）
+//   - timeBetween(r.attrs, "09:00", "18:00"): 時刻（サーバのタイムゾーン）が範囲内か。開始 > 終了なら日をまたぐ範囲
+//   - isWeekday(r.attrs): 平日（月〜金）か
+//   - ipInRange(r.attrs, "10.0.0.0/8"): IP アドレスが CIDR の範囲内か
+//   - hasTag(r.attrs, "env", "production"): リソースのタグが一致するか
+var conditionFunctions = map[string]govaluate.ExpressionFunction{
+	"timeBetween": conditionFunc(3, func(attrs map[string]interface{}, args []string) bool {
+		t, ok := requestTime(attrs)
+		if !ok {
+			return false
+		}
+		from, err1 := time.Parse("15:04", args[0])
+		to, err2 := time.Parse("15:04", args[1])
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		minutes := t.Hour()*60 + t.Minute()
+		start := from.Hour()*60 + from.Minute()
+		end := to.Hour()*60 + to.Minute()
+		if start <= end {
+			return start <= minutes && minutes < end
+		}
+		return minutes >= start || minutes < end
+	}),
+
+	"isWeekday": conditionFunc(1, func(attrs map[string]interface{}, _ []string) bool {
+		t, ok := requestTime(attrs)
+		return ok && t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
+	}),
+
+	"ipInRange": conditionFunc(2, func(attrs map[string]interface{}, args []string) bool {
+		s, _ := attrs[attrIP].(string)
+		ip := net.ParseIP(s)
+		_, network, err := net.ParseCIDR(args[0])
+		return ip != nil && err == nil && network.Contains(ip)
+	}),
+
+	"hasTag": conditionFunc(3, func(attrs map[string]interface{}, args []string) bool {
+		tags, _ := attrs[attrTags].(map[string]interface{})
+		value, _ := tags[args[0]].(string)
+		return value == args[1]
+	}),
+}
+
+// registerConditionFunctions は cond で使う関数を Enforcer に登録する
+func registerConditionFunctions(e *casbin.SyncedEnforcer) {
+	for name, fn := range conditionFunctions {
+		e.AddFunction(name, fn)
+	}
+}
+
+// validateCondition は cond を式として解釈できるか確認する。
+// 解釈できない cond の p 行があると、その p 行を評価するすべての判定がエラーになるため、追加前に確認する
+func validateCondition(cond string) error {
+	fm := model.LoadFunctionMap()
+	for name, fn := range conditionFunctions {
+		fm.AddFunction(name, fn)
+	}
+	if _, err := govaluate.NewEvaluableExpressionWithFunctions(util.EscapeAssertion(cond), fm.GetFunctions()); err != nil {
+		return fmt.Errorf("invalid cond %q: %w", cond, err)
+	}
+	return nil
+}
+
+// conditionFunc は (r.attrs, 文字列の引数...) を受け取る関数を Casbin の関数にする
+func conditionFunc(arity int, fn func(attrs map[string]interface{}, args []string) bool) func(args ...interface{}) (interface{}, error) {
+	return func(args ...interface{}) (interface{}, error) {
+		if len(args) != arity {
+			return false, fmt.Errorf("expected %d arguments, got %d", arity, len(args))
+		}
+		attrs, _ := args[0].(map[string]interface{})
+		strs := make([]string, 0, arity-1)
+		for _, arg := range args[1:] {
+			s, ok := arg.(string)
+			if !ok {
+				return false, fmt.Errorf("argument must be a string: %v", arg)
+			}
+			strs = append(strs, s)
+		}
+		return fn(attrs, strs), nil
+	}
+}
+
+// requestTime は属性の time をサーバのタイムゾーンの時刻にする
+func requestTime(attrs map[string]interface{}) (time.Time, bool) {
+	s, _ := attrs[attrTime].(string)
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t.In(time.Local), true
+}
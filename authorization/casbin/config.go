@@ -0,0 +1,321 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ストレージの種類
+const (
+	storageFile     = "file"
+	storagePostgres = "postgres"
+)
+
+// Config は Casbin 認可サーバの設定。
+// 既定値 < 設定ファイル（YAML、-config / CASBIN_CONFIG） < 環境変数 < コマンドラインフラグ の順に上書きする
+type Config struct {
+	Port int `yaml:"port"`
+	// debug / info / warn / error
+	LogLevel string `yaml:"log_level"`
+
+	// 既定のテナントのモデルとポリシー
+	Model  string `yaml:"model"`
+	Policy string `yaml:"policy"`
+	// default 以外のテナント。<tenants_dir>/<テナント>/model.conf・policy.csv を使う
+	Tenants    []string `yaml:"tenants"`
+	TenantsDir string   `yaml:"tenants_dir"`
+
+	Storage  StorageConfig     `yaml:"storage"`
+	Postgres PostgresConfig    `yaml:"postgres"`
+	Auth     serviceAuthConfig `yaml:"auth"`
+
+	// CORSで許可するオリジン（フロントエンドの開発サーバ）
+	CORSAllowedOrigins []string `yaml:"cors_allowed_origins"`
+}
+
+type StorageConfig struct {
+	// file / postgres
+	Type string `yaml:"type"`
+	// 起動時に policy.csv の内容を DB に読み込む（postgres のみ）
+	LoadInitialPolicies bool `yaml:"load_initial_policies"`
+	// PostgreSQL に接続できない場合に policy.csv で起動する。false の場合は起動に失敗する
+	AllowFileFallback bool `yaml:"allow_file_fallback"`
+}
+
+type PostgresConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Database string `yaml:"database"`
+	SSLMode  string `yaml:"sslmode"`
+}
+
+// dsn は Casbin の PostgreSQL の接続文字列
+func (c PostgresConfig) dsn() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=Asia/Tokyo",
+		c.Host, c.User, c.Password, c.Database, c.Port, c.SSLMode)
+}
+
+// defaultConfig は設定ファイル・環境変数・フラグで指定しなかった項目の値。パスワードと preshared key の既定値はない
+func defaultConfig() Config {
+	return Config{
+		Port:       8080,
+		LogLevel:   "info",
+		Model:      "model.conf",
+		Policy:     "policy.csv",
+		TenantsDir: "tenants",
+		Storage:    StorageConfig{Type: storageFile},
+		Postgres: PostgresConfig{
+			Host:     "localhost",
+			Port:     5437,
+			User:     "casbin",
+			Database: "casbin",
+			SSLMode:  "disable",
+		},
+		CORSAllowedOrigins: []string{"http://localhost:3000", "http://localhost:3001"},
+	}
+}
+
+// loadConfig はコマンドラインフラグ（args）・設定ファイル・環境変数から設定を読み込み、検証する
+func loadConfig(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("casbin-authorization-server", flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv("CASBIN_CONFIG"), "設定ファイル（YAML）")
+	var flags Config
+	var tenants string
+	fs.IntVar(&flags.Port, "port", 0, "待ち受けるポート")
+	fs.StringVar(&flags.LogLevel, "log-level", "", "ログのレベル（debug / info / warn / error）")
+	fs.StringVar(&flags.Model, "model", "", "既定のテナントの model.conf")
+	fs.StringVar(&flags.Policy, "policy", "", "既定のテナントの policy.csv")
+	fs.StringVar(&tenants, "tenants", "", "default 以外のテナント（カンマ区切り）")
+	fs.StringVar(&flags.TenantsDir, "tenants-dir", "", "テナントの model.conf / policy.csv を置くディレクトリ")
+	fs.StringVar(&flags.Storage.Type, "storage", "", "ストレージ（file / postgres）")
+	fs.BoolVar(&flags.Storage.LoadInitialPolicies, "load-initial-policies", false, "起動時に policy.csv を DB に読み込む")
+	fs.BoolVar(&flags.Storage.AllowFileFallback, "allow-file-fallback", false, "PostgreSQL に接続できない場合に policy.csv で起動する")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := defaultConfig()
+	if *configPath != "" {
+		data, err := os.ReadFile(*configPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", *configPath, err)
+		}
+	}
+	envErr := cfg.applyEnv()
+
+	// 指定されたフラグのみ上書きする
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "port":
+			cfg.Port = flags.Port
+		case "log-level":
+			cfg.LogLevel = flags.LogLevel
+		case "model":
+			cfg.Model = flags.Model
+		case "policy":
+			cfg.Policy = flags.Policy
+		case "tenants":
+			cfg.Tenants = splitList(tenants)
+		case "tenants-dir":
+			cfg.TenantsDir = flags.TenantsDir
+		case "storage":
+			cfg.Storage.Type = flags.Storage.Type
+		case "load-initial-policies":
+			cfg.Storage.LoadInitialPolicies = flags.Storage.LoadInitialPolicies
+		case "allow-file-fallback":
+			cfg.Storage.AllowFileFallback = flags.Storage.AllowFileFallback
+		}
+	})
+
+	if err := errors.Join(envErr, cfg.validate()); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return &cfg, nil
+}
+
+// applyEnv は設定された環境変数で上書きする（USE_POSTGRES などの従来の環境変数も使える）
+func (c *Config) applyEnv() error {
+	var errs []error
+	str := func(name string, dst *string) {
+		if v, ok := os.LookupEnv(name); ok {
+			*dst = v
+		}
+	}
+	integer := func(name string, dst *int) {
+		if v, ok := os.LookupEnv(name); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s must be an integer: %q", name, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	boolean := func(name string, dst *bool) {
+		if v, ok := os.LookupEnv(name); ok {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s must be true or false: %q", name, v))
+				return
+			}
+			*dst = b
+		}
+	}
+	list := func(name string, dst *[]string) {
+		if v, ok := os.LookupEnv(name); ok {
+			*dst = splitList(v)
+		}
+	}
+
+	integer("PORT", &c.Port)
+	str("LOG_LEVEL", &c.LogLevel)
+	str("CASBIN_MODEL", &c.Model)
+	str("CASBIN_POLICY", &c.Policy)
+	list("CASBIN_TENANTS", &c.Tenants)
+	str("CASBIN_TENANTS_DIR", &c.TenantsDir)
+
+	if _, ok := os.LookupEnv("USE_POSTGRES"); ok {
+		usePostgres := false
+		boolean("USE_POSTGRES", &usePostgres)
+		c.Storage.Type = storageFile
+		if usePostgres {
+			c.Storage.Type = storagePostgres
+		}
+	}
+	str("CASBIN_STORAGE", &c.Storage.Type)
+	boolean("LOAD_INITIAL_POLICIES", &c.Storage.LoadInitialPolicies)
+	boolean("CASBIN_ALLOW_FILE_FALLBACK", &c.Storage.AllowFileFallback)
+
+	str("CASBIN_DB_HOST", &c.Postgres.Host)
+	integer("CASBIN_DB_PORT", &c.Postgres.Port)
+	str("CASBIN_DB_USER", &c.Postgres.User)
+	str("CASBIN_DB_PASSWORD", &c.Postgres.Password)
+	str("CASBIN_DB_NAME", &c.Postgres.Database)
+	str("CASBIN_DB_SSLMODE", &c.Postgres.SSLMode)
+
+	str("CASBIN_PRESHARED_KEY", &c.Auth.PresharedKey)
+	str("CASBIN_TLS_CERT_FILE", &c.Auth.CertFile)
+	str("CASBIN_TLS_KEY_FILE", &c.Auth.KeyFile)
+	str("CASBIN_TLS_CLIENT_CA_FILE", &c.Auth.ClientCAFile)
+	list("CORS_ALLOWED_ORIGINS", &c.CORSAllowedOrigins)
+
+	return errors.Join(errs...)
+}
+
+// validate は設定の誤りをすべてまとめて返す
+func (c *Config) validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Port < 1 || c.Port > 65535 {
+		fail("port must be between 1 and 65535: %d", c.Port)
+	}
+	if _, err := parseLogLevel(c.LogLevel); err != nil {
+		fail("log_level must be debug, info, warn or error: %q", c.LogLevel)
+	}
+
+	usePolicyFile := c.Storage.Type == storageFile || c.Storage.LoadInitialPolicies || c.Storage.AllowFileFallback
+	requireFile := func(key, path string) {
+		if path == "" {
+			fail("%s is required", key)
+		} else if _, err := os.Stat(path); err != nil {
+			fail("%s: %v", key, err)
+		}
+	}
+	requireFile("model", c.Model)
+	if usePolicyFile {
+		requireFile("policy", c.Policy)
+	}
+
+	seen := map[string]bool{}
+	for _, name := range c.Tenants {
+		switch {
+		case name == defaultTenant:
+			fail("tenants: %q is always enabled and must not be listed", defaultTenant)
+		case !tenantNamePattern.MatchString(name):
+			fail("tenants: invalid tenant name %q: must match %s", name, tenantNamePattern)
+		case seen[name]:
+			fail("tenants: duplicate tenant %q", name)
+		default:
+			modelPath, policyPath := c.tenantPaths(name)
+			requireFile("model of tenant "+name, modelPath)
+			if usePolicyFile {
+				requireFile("policy of tenant "+name, policyPath)
+			}
+		}
+		seen[name] = true
+	}
+
+	switch c.Storage.Type {
+	case storageFile:
+	case storagePostgres:
+		if c.Postgres.Host == "" {
+			fail("postgres.host is required")
+		}
+		if c.Postgres.Port < 1 || c.Postgres.Port > 65535 {
+			fail("postgres.port must be between 1 and 65535: %d", c.Postgres.Port)
+		}
+		if c.Postgres.User == "" {
+			fail("postgres.user is required")
+		}
+		if c.Postgres.Password == "" {
+			fail("postgres.password is required (CASBIN_DB_PASSWORD)")
+		}
+		if c.Postgres.Database == "" {
+			fail("postgres.database is required")
+		}
+		switch c.Postgres.SSLMode {
+		case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+		default:
+			fail("postgres.sslmode is invalid: %q", c.Postgres.SSLMode)
+		}
+	default:
+		fail("storage.type must be %s or %s: %q", storageFile, storagePostgres, c.Storage.Type)
+	}
+
+	if err := c.Auth.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// tenantPaths はテナントの model.conf と policy.csv のパス
+func (c *Config) tenantPaths(name string) (string, string) {
+	if name == defaultTenant {
+		return c.Model, c.Policy
+	}
+	dir := filepath.Join(c.TenantsDir, name)
+	return filepath.Join(dir, "model.conf"), filepath.Join(dir, "policy.csv")
+}
+
+func parseLogLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(strings.ToUpper(s)))
+	return level, err
+}
+
+// splitList はカンマ区切りの値を空白を除いて分ける
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ロールはドメインごとに割り当てる（g = ユーザー, ロール, ドメイン）。
+// リソースのドメインは "<種別>:<ID>"（例: system:system1）で、owner / manager / staff などのロールの権限は
+// policy.csv でリソース種別ごとに1回だけ定義する（p, owner, system:*, /system/*, GET）。
+// globalDomain に割り当てたロール（admin など）はすべてのドメインで有効
+const globalDomain = "global"
+
+// domainForObject はオブジェクトのパス（/<種別>/<ID>/...）からドメインを求める。
+// リソースを指さないパス（/system-list など）は globalDomain
+func domainForObject(object string) string {
+	parts := strings.SplitN(strings.TrimPrefix(object, "/"), "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return globalDomain
+	}
+	return parts[0] + ":" + parts[1]
+}
+
+// resolveDomain はリクエストのドメインを決める。省略時はオブジェクトから求め、
+// 指定された場合はオブジェクトが別のドメインを指していないかを確認する
+func resolveDomain(domain, object string) (string, error) {
+	derived := domainForObject(object)
+	if domain == "" {
+		return derived, nil
+	}
+	if derived != globalDomain && domain != derived {
+		return "", fmt.Errorf("object %s is not in domain %s", object, domain)
+	}
+	return domain, nil
+}
+
+// legacyRole はドメイン導入前のロール名（system_owner:system1 形式）。
+// 既存のフロントエンドがロール名で表示・更新しているため、相互に変換する
+func legacyRole(role, domain string) string {
+	resourceType, resourceID, ok := strings.Cut(domain, ":")
+	if !ok {
+		return role
+	}
+	return resourceType + "_" + role + ":" + resourceID
+}
+
+// parseLegacyRole は legacyRole の逆変換。ドメインのないロール（admin など）は globalDomain に割り当てる
+func parseLegacyRole(name string) (role, domain string) {
+	prefix, resourceID, ok := strings.Cut(name, ":")
+	if !ok {
+		return name, globalDomain
+	}
+	resourceType, role, ok := strings.Cut(prefix, "_")
+	if !ok {
+		return name, globalDomain
+	}
+	return role, resourceType + ":" + resourceID
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// p 行の最後の列（eft）。deny の行に一致した場合は allow の行より優先して拒否する（deny-override）
+const (
+	effectAllow = "allow"
+	effectDeny  = "deny"
+)
+
+// policyFields は eft と cond を除いた p 行の列数（sub, dom, obj, act）
+const policyFields = 4
+
+// p 行の eft と cond の列
+const (
+	effectIndex    = policyFields
+	conditionIndex = policyFields + 1
+)
+
+// normalizePolicy は PolicyRequest を eft と cond 付きの p 行にする。
+// eft / cond は policy の5・6列目か eft / cond フィールドで指定し、省略時は allow / true
+func normalizePolicy(req PolicyRequest) ([]string, error) {
+	if len(req.Policy) < policyFields || len(req.Policy) > conditionIndex+1 {
+		return nil, fmt.Errorf("policy must have 4 to 6 elements: subject, domain, object, action[, eft[, cond]]")
+	}
+
+	policy := append([]string{}, req.Policy...)
+	for _, column := range []struct {
+		index    int
+		name     string
+		value    string
+		fallback string
+	}{
+		{effectIndex, "eft", req.Eft, effectAllow},
+		{conditionIndex, "cond", req.Cond, conditionAlways},
+	} {
+		switch {
+		case len(policy) > column.index:
+			if column.value != "" && column.value != policy[column.index] {
+				return nil, fmt.Errorf("%s %q does not match policy %s %q", column.name, column.value, column.name, policy[column.index])
+			}
+		case column.value != "":
+			policy = append(policy, column.value)
+		default:
+			policy = append(policy, column.fallback)
+		}
+	}
+	return validateEffect(policy)
+}
+
+func validateEffect(policy []string) ([]string, error) {
+	if eft := policy[effectIndex]; eft != effectAllow && eft != effectDeny {
+		return nil, fmt.Errorf("eft must be %s or %s: %s", effectAllow, effectDeny, eft)
+	}
+	if err := validateCondition(policy[conditionIndex]); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// decisionReason は EnforceEx が返した一致した p 行から判定理由を作る
+func decisionReason(allowed bool, matched []string) string {
+	switch {
+	case allowed:
+		return "Allowed by policy: p, " + strings.Join(matched, ", ")
+	case len(matched) > 0:
+		return "Denied by policy: p, " + strings.Join(matched, ", ")
+	default:
+		return "Access denied by policy: no matching allow rule"
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/casbin/casbin/v2/util"
+)
+
+// 候補として返す p 行の最大件数
+const maxCandidates = 10
+
+// Explanation は /authorize?explain=true で返す判定の説明
+type Explanation struct {
+	Domain string `json:"domain"`
+	// 判定を決めた p 行（一致する行がない場合は空）
+	Matched []string `json:"matched,omitempty"`
+	// ユーザーから Matched の sub までのロールの経路（例: jiro -> owner [system:system1] -> manager [system:*]）
+	RoleChain string `json:"role_chain,omitempty"`
+	// 拒否された場合に、条件を1つ（なければ2つ）だけ満たしていない allow の p 行
+	Candidates []Candidate `json:"candidates,omitempty"`
+}
+
+// Candidate は拒否時に「あと少しで一致した」p 行と、満たしていない条件（subject / domain / object / action / condition）
+type Candidate struct {
+	Policy  []string `json:"policy"`
+	Missing []string `json:"missing"`
+}
+
+// explain は EnforceEx の結果に、ロールの経路と拒否時の候補を加える
+func (t *Tenant) explain(subject, domain, object, action string, allowed bool, matched []string) *Explanation {
+	e := &Explanation{Domain: domain, Matched: matched}
+	if len(matched) > 0 {
+		e.RoleChain = t.roleChain(subject, matched[0], domain)
+	}
+	if !allowed {
+		e.Candidates = t.candidates(subject, domain, object, action)
+	}
+	return e
+}
+
+type roleEdge struct {
+	role   string
+	domain string
+}
+
+// roleChain は subject から role までの g 行の経路を返す。
+// マッチャーと同じく、ドメインの g 行で見つからなければ globalDomain の g 行をたどる
+func (t *Tenant) roleChain(subject, role, domain string) string {
+	if subject == role {
+		return subject
+	}
+	for _, d := range []string{domain, globalDomain} {
+		if path := t.findRolePath(subject, role, d); path != nil {
+			var b strings.Builder
+			b.WriteString(subject)
+			for _, step := range path {
+				b.WriteString(" -> " + step.role + " [" + step.domain + "]")
+			}
+			return b.String()
+		}
+	}
+	return ""
+}
+
+// findRolePath は domain に一致する g 行（ドメインのパターンを含む）を幅優先でたどる
+func (t *Tenant) findRolePath(subject, role, domain string) []roleEdge {
+	edges := map[string][]roleEdge{}
+	for _, g := range t.Enforcer.GetGroupingPolicy() {
+		if len(g) >= 3 && util.KeyMatch(domain, g[2]) {
+			edges[g[0]] = append(edges[g[0]], roleEdge{role: g[1], domain: g[2]})
+		}
+	}
+
+	prev := map[string]roleEdge{}
+	from := map[string]string{}
+	visited := map[string]bool{subject: true}
+	queue := []string{subject}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, edge := range edges[name] {
+			if visited[edge.role] {
+				continue
+			}
+			visited[edge.role] = true
+			prev[edge.role] = edge
+			from[edge.role] = name
+			if edge.role == role {
+				var path []roleEdge
+				for n := role; n != subject; n = from[n] {
+					path = append([]roleEdge{prev[n]}, path...)
+				}
+				return path
+			}
+			queue = append(queue, edge.role)
+		}
+	}
+	return nil
+}
+
+// hasRole はマッチャーの g(r.sub, p.sub, r.dom) || g(r.sub, p.sub, "global") と同じ判定
+func (t *Tenant) hasRole(subject, role, domain string) bool {
+	rm := t.Enforcer.GetRoleManager()
+	if ok, _ := rm.HasLink(subject, role, domain); ok {
+		return true
+	}
+	ok, _ := rm.HasLink(subject, role, globalDomain)
+	return ok
+}
+
+// candidates は満たしていない条件が少ない allow の p 行を返す（1つだけの行があればそれのみ）
+func (t *Tenant) candidates(subject, domain, object, action string) []Candidate {
+	byMissing := map[int][]Candidate{}
+	for _, p := range t.Enforcer.GetPolicy() {
+		if len(p) <= conditionIndex || p[effectIndex] != effectAllow {
+			continue
+		}
+
+		var missing []string
+		if !t.hasRole(subject, p[0], domain) {
+			missing = append(missing, "subject")
+		}
+		if !util.KeyMatch(domain, p[1]) {
+			missing = append(missing, "domain")
+		}
+		if !util.KeyMatch(object, p[2]) {
+			missing = append(missing, "object")
+		}
+		if p[3] != "*" && p[3] != action {
+			missing = append(missing, "action")
+		}
+		// 他の条件をすべて満たしていて拒否された場合は、属性の条件（cond）を満たしていない
+		if len(missing) == 0 && p[conditionIndex] != conditionAlways {
+			missing = append(missing, "condition")
+		}
+		if n := len(missing); n == 1 || n == 2 {
+			byMissing[n] = append(byMissing[n], Candidate{Policy: p, Missing: missing})
+		}
+	}
+
+	result := byMissing[1]
+	if len(result) == 0 {
+		result = byMissing[2]
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return strings.Join(result[i].Policy, ",") < strings.Join(result[j].Policy, ",")
+	})
+	if len(result) > maxCandidates {
+		result = result[:maxCandidates]
+	}
+	return result
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+	gormadapter "github.com/casbin/gorm-adapter/v3"
+	"gorm.io/gorm"
+)
+
+// インポートで受け付ける本文の最大サイズ
+const maxImportSize = 10 << 20
+
+// PolicySet はインポート・エクスポートする p 行と g 行
+type PolicySet struct {
+	Policies [][]string `json:"policies"`
+	Groups   [][]string `json:"groups"`
+}
+
+// ImportResponse は現在のポリシーとの差分。dry_run の場合は適用しない
+type ImportResponse struct {
+	DryRun  bool      `json:"dry_run"`
+	Mode    string    `json:"mode"`
+	Added   PolicySet `json:"added"`
+	Removed PolicySet `json:"removed"`
+}
+
+// インポートのモード
+const (
+	importReplace = "replace" // インポートした内容に置き換える（含まれない行は削除する）
+	importMerge   = "merge"   // 含まれない行は削除しない
+)
+
+// ポリシーをエクスポートするハンドラ。format=csv の場合は policy.csv と同じ形式で返す
+func exportPoliciesHandler(w http.ResponseWriter, r *http.Request) {
+	set := tenantFrom(r).currentPolicySet()
+
+	if r.URL.Query().Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="policy.csv"`)
+		for _, p := range set.Policies {
+			fmt.Fprintln(w, csvLine("p", p))
+		}
+		for _, g := range set.Groups {
+			fmt.Fprintln(w, csvLine("g", g))
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(set)
+}
+
+// ポリシーをインポートするハンドラ。本文は Content-Type が text/csv なら policy.csv と同じ形式、それ以外は PolicySet の JSON。
+// mode=replace（既定）は含まれない行を削除し、mode=merge は追加のみ行う。dry_run=true の場合は差分だけを返す
+func importPoliciesHandler(w http.ResponseWriter, r *http.Request) {
+	t := tenantFrom(r)
+	query := r.URL.Query()
+	mode := query.Get("mode")
+	if mode == "" {
+		mode = importReplace
+	}
+	if mode != importReplace && mode != importMerge {
+		http.Error(w, "mode must be replace or merge", http.StatusBadRequest)
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxImportSize)
+	var set PolicySet
+	var err error
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "text/csv" {
+		set, err = parsePolicyCSV(body)
+	} else {
+		err = json.NewDecoder(body).Decode(&set)
+	}
+	if err != nil {
+		http.Error(w, "Invalid import body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	// 省略した項目の行をすべて削除しないよう、置き換える場合は両方を必須にする
+	if mode == importReplace && (set.Policies == nil || set.Groups == nil) {
+		http.Error(w, "policies and groups are required in replace mode (use [] to remove all)", http.StatusBadRequest)
+		return
+	}
+	if err := normalizePolicySet(&set); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := ImportResponse{DryRun: query.Get("dry_run") == "true", Mode: mode}
+	response.Added, response.Removed, err = t.importPolicies(set, mode == importReplace, response.DryRun)
+	if err != nil {
+		http.Error(w, "Failed to import policies: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !response.DryRun {
+		slog.InfoContext(r.Context(), "Policies imported",
+			"added_policies", len(response.Added.Policies), "removed_policies", len(response.Removed.Policies),
+			"added_groups", len(response.Added.Groups), "removed_groups", len(response.Removed.Groups))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (t *Tenant) currentPolicySet() PolicySet {
+	return PolicySet{Policies: t.Enforcer.GetPolicy(), Groups: t.Enforcer.GetGroupingPolicy()}
+}
+
+// parsePolicyCSV は policy.csv と同じ形式（"p, sub, dom, obj, act, eft, cond" / "g, user, role, dom"、# はコメント）を読み込む
+func parsePolicyCSV(r io.Reader) (PolicySet, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	set := PolicySet{Policies: [][]string{}, Groups: [][]string{}}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return set, nil
+		}
+		if err != nil {
+			return set, err
+		}
+		switch record[0] {
+		case "p":
+			set.Policies = append(set.Policies, record[1:])
+		case "g":
+			set.Groups = append(set.Groups, record[1:])
+		default:
+			line, _ := reader.FieldPos(0)
+			return set, fmt.Errorf("line %d: unknown policy type %q", line, record[0])
+		}
+	}
+}
+
+// normalizePolicySet は p 行を eft / cond 付きにして検証し、g 行の列数を確認する
+func normalizePolicySet(set *PolicySet) error {
+	for i, p := range set.Policies {
+		policy, err := normalizePolicy(PolicyRequest{Policy: p})
+		if err != nil {
+			return fmt.Errorf("policies[%d]: %w", i, err)
+		}
+		set.Policies[i] = policy
+	}
+	for i, g := range set.Groups {
+		if len(g) != 3 {
+			return fmt.Errorf("groups[%d]: group must have 3 elements: user, role, domain", i)
+		}
+	}
+	return nil
+}
+
+// diffPolicySet は current から desired にするために追加・削除する行を返す。remove が false の場合は削除しない
+func diffPolicySet(current, desired PolicySet, remove bool) (added, removed PolicySet) {
+	added.Policies, removed.Policies = diffRules(current.Policies, desired.Policies)
+	added.Groups, removed.Groups = diffRules(current.Groups, desired.Groups)
+	if !remove {
+		removed = PolicySet{Policies: [][]string{}, Groups: [][]string{}}
+	}
+	return added, removed
+}
+
+func diffRules(current, desired [][]string) (added, removed [][]string) {
+	key := func(rule []string) string { return strings.Join(rule, "\x00") }
+	currentSet := map[string]bool{}
+	for _, rule := range current {
+		currentSet[key(rule)] = true
+	}
+	desiredSet := map[string]bool{}
+	added, removed = [][]string{}, [][]string{}
+	for _, rule := range desired {
+		k := key(rule)
+		if !currentSet[k] && !desiredSet[k] {
+			added = append(added, rule)
+		}
+		desiredSet[k] = true
+	}
+	for _, rule := range current {
+		if !desiredSet[key(rule)] {
+			removed = append(removed, rule)
+		}
+	}
+	return added, removed
+}
+
+// importPolicies は現在のポリシーを desired にするための差分を計算し、dryRun でなければ適用する。
+// 同じテナントのインポートは直列化し、PostgreSQL の場合は差分の計算から書き込みまでを1つのトランザクションで行う。
+// コミット後に DB から読み込み直し、他のインスタンスには再読み込みを通知する
+func (t *Tenant) importPolicies(desired PolicySet, remove, dryRun bool) (added, removed PolicySet, err error) {
+	t.importMu.Lock()
+	defer t.importMu.Unlock()
+
+	if t.adapter == nil {
+		added, removed = diffPolicySet(t.currentPolicySet(), desired, remove)
+		if dryRun {
+			return added, removed, nil
+		}
+		return added, removed, applyToEnforcer(t.Enforcer, added, removed)
+	}
+
+	// gorm-adapter の Transaction は終了時の読み込みに失敗すると panic するため使わず、
+	// テナントのテーブルを指定したアダプターをトランザクションの中で作って読み書きする
+	err = t.adapter.GetDb().Transaction(func(tx *gorm.DB) error {
+		// 他のインスタンスのインポートとも直列化する（ロックはトランザクションの終了時に解放される）
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", tenantTable(t.Name)).Error; err != nil {
+			return fmt.Errorf("failed to lock policies: %w", err)
+		}
+		gormadapter.TurnOffAutoMigrate(tx)
+		txAdapter, err := gormadapter.NewAdapterByDBUseTableName(tx, "", tenantTable(t.Name))
+		if err != nil {
+			return fmt.Errorf("failed to create GORM adapter: %w", err)
+		}
+		// 通知の遅れや再読み込みの失敗でメモリ上のポリシーが古い場合があるため、差分は DB の行から計算する
+		current, err := loadPolicySet(txAdapter, t.Enforcer.GetModel())
+		if err != nil {
+			return err
+		}
+		added, removed = diffPolicySet(current, desired, remove)
+		if dryRun {
+			return nil
+		}
+		return applyToAdapter(txAdapter, added, removed)
+	})
+	if err != nil || dryRun {
+		return added, removed, err
+	}
+
+	// DB にはコミット済みのため、読み込みに失敗した場合も他のインスタンスには通知する
+	if err = t.Enforcer.LoadPolicy(); err != nil {
+		err = fmt.Errorf("failed to reload policies: %w", err)
+	}
+	if t.watcher != nil {
+		err = errors.Join(err, t.watcher.Update())
+	}
+	return added, removed, err
+}
+
+// loadPolicySet はアダプターからポリシーを読み込む。Enforcer のモデルは変更せず、コピーに読み込む
+func loadPolicySet(a persist.Adapter, m model.Model) (PolicySet, error) {
+	m = m.Copy()
+	m.ClearPolicy()
+	if err := a.LoadPolicy(m); err != nil {
+		return PolicySet{}, fmt.Errorf("failed to load policies: %w", err)
+	}
+	return PolicySet{Policies: m.GetPolicy("p", "p"), Groups: m.GetPolicy("g", "g")}, nil
+}
+
+// applyToEnforcer は差分をメモリ上のポリシーに適用する（ファイルストレージの場合）。
+// 各手順は一括で適用され、1行でも適用できない場合は何も変更しない。途中の手順が失敗した場合は適用済みの手順を逆順に取り消す
+func applyToEnforcer(e casbin.IEnforcer, added, removed PolicySet) error {
+	steps := []struct {
+		name        string
+		rules       [][]string
+		apply, undo func([][]string) (bool, error)
+	}{
+		{"remove policies", removed.Policies, e.RemovePolicies, e.AddPolicies},
+		{"remove groups", removed.Groups, e.RemoveGroupingPolicies, e.AddGroupingPolicies},
+		{"add policies", added.Policies, e.AddPolicies, e.RemovePolicies},
+		{"add groups", added.Groups, e.AddGroupingPolicies, e.RemoveGroupingPolicies},
+	}
+	for i, step := range steps {
+		if len(step.rules) == 0 {
+			continue
+		}
+		ok, err := step.apply(step.rules)
+		if err == nil && !ok {
+			// 差分は現在のポリシーから計算しているため、インポート以外の変更と競合した場合のみ起こる
+			err = errors.New("policies were changed concurrently")
+		}
+		if err == nil {
+			continue
+		}
+		for j := i - 1; j >= 0; j-- {
+			if len(steps[j].rules) == 0 {
+				continue
+			}
+			if _, undoErr := steps[j].undo(steps[j].rules); undoErr != nil {
+				slog.Error("Failed to roll back policy import", "step", steps[j].name, "error", undoErr)
+			}
+		}
+		return fmt.Errorf("failed to %s: %w", step.name, err)
+	}
+	return nil
+}
+
+// applyToAdapter は差分を DB に書き込む。gorm-adapter の RemovePolicies は削除の失敗を無視するため1行ずつ削除する
+func applyToAdapter(a *gormadapter.Adapter, added, removed PolicySet) error {
+	for _, rule := range removed.Policies {
+		if err := a.RemovePolicy("p", "p", rule); err != nil {
+			return fmt.Errorf("failed to remove policies: %w", err)
+		}
+	}
+	for _, rule := range removed.Groups {
+		if err := a.RemovePolicy("g", "g", rule); err != nil {
+			return fmt.Errorf("failed to remove groups: %w", err)
+		}
+	}
+	if len(added.Policies) > 0 {
+		if err := a.AddPolicies("p", "p", added.Policies); err != nil {
+			return fmt.Errorf("failed to add policies: %w", err)
+		}
+	}
+	if len(added.Groups) > 0 {
+		if err := a.AddPolicies("g", "g", added.Groups); err != nil {
+			return fmt.Errorf("failed to add groups: %w", err)
+		}
+	}
+	return nil
+}
+
+// csvLine は policy.csv の1行にする。カンマや引用符を含む値（cond など）は引用符で囲む
+func csvLine(ptype string, rule []string) string {
+	fields := []string{ptype}
+	for _, field := range rule {
+		if strings.ContainsAny(field, ",\"") {
+			field = `"` + strings.ReplaceAll(field, `"`, `""`) + `"`
+		}
+		fields = append(fields, field)
+	}
+	return strings.Join(fields, ", ")
+}
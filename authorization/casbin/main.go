@@ -2,156 +2,120 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"time"
 
-	"github.com/casbin/casbin/v2"
-	gormadapter "github.com/casbin/gorm-adapter/v3"
 	"github.com/gorilla/mux"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
 type AuthRequest struct {
 	Subject string `json:"subject"`
-	Object  string `json:"object"`
-	Action  string `json:"action"`
+	// 省略時はオブジェクトのパスから求める（/system/system1 → system:system1）
+	Domain string `json:"domain,omitempty"`
+	Object string `json:"object"`
+	Action string `json:"action"`
+	// ABAC の条件（p 行の cond）で参照する属性（time / ip / tags）。time の省略時はリクエストを受けた時刻
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
 }
 
 type AuthResponse struct {
 	Allowed bool   `json:"allowed"`
 	Reason  string `json:"reason,omitempty"`
+	// /authorize?explain=true の場合のみ
+	Explanation *Explanation `json:"explanation,omitempty"`
 }
 
+// 一括認可チェック用の構造体
+type BatchAuthRequest struct {
+	Requests []AuthRequest `json:"requests"`
+}
+
+type BatchAuthResponse struct {
+	Results []AuthResponse `json:"results"`
+}
+
+// 一括認可チェックで受け付ける最大件数
+const maxBatchSize = 1000
+
+// Policy は sub, dom, obj, act[, eft[, cond]]。eft（allow / deny）と cond（属性の条件）は Eft / Cond でも指定でき、
+// 省略時は allow / true
 type PolicyRequest struct {
 	Policy []string `json:"policy"`
+	Eft    string   `json:"eft,omitempty"`
+	Cond   string   `json:"cond,omitempty"`
 }
 
 // ロール管理用の構造体を追加
+// Domain を省略した場合、Role は従来のロール名（system_owner:system1 / admin）として解釈する
 type RoleRequest struct {
-	User string `json:"user"`
-	Role string `json:"role"`
+	User   string `json:"user"`
+	Role   string `json:"role"`
+	Domain string `json:"domain,omitempty"`
 }
 
-type UserRolesResponse struct {
-	User  string   `json:"user"`
-	Roles []string `json:"roles"`
+type DomainRole struct {
+	Role   string `json:"role"`
+	Domain string `json:"domain"`
 }
 
-var enforcer *casbin.Enforcer
+type UserRolesResponse struct {
+	User string `json:"user"`
+	// 従来のロール名（system_owner:system1 形式）
+	Roles       []string     `json:"roles"`
+	DomainRoles []DomainRole `json:"domain_roles"`
+}
 
 // PostgreSQL接続関数
-func connectToPostgreSQL() *gorm.DB {
-	dbHost := os.Getenv("CASBIN_DB_HOST")
-	if dbHost == "" {
-		dbHost = "localhost"
-	}
-	
-	dbPort := os.Getenv("CASBIN_DB_PORT")
-	if dbPort == "" {
-		dbPort = "5437"
-	}
-
-	dbUser := os.Getenv("CASBIN_DB_USER")
-	if dbUser == "" {
-		dbUser = "casbin"
-	}
-
-	dbPassword := os.Getenv("CASBIN_DB_PASSWORD")
-	if dbPassword == "" {
-		dbPassword = "casbin123"
-	}
-
-	dbName := os.Getenv("CASBIN_DB_NAME")
-	if dbName == "" {
-		dbName = "casbin"
-	}
-
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Tokyo",
-		dbHost, dbUser, dbPassword, dbName, dbPort)
+func connectToPostgreSQL(cfg PostgresConfig) (*gorm.DB, error) {
+	dsn := cfg.dsn()
+
+	// GORM のログ（遅いクエリ・エラー）も slog の JSON で出す
+	gormLogger := logger.New(slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn), logger.Config{
+		SlowThreshold:             200 * time.Millisecond,
+		LogLevel:                  logger.Warn,
+		IgnoreRecordNotFoundError: true,
+	})
 
 	// データベースに接続するまでリトライ
 	var db *gorm.DB
 	var err error
 	for i := 0; i < 30; i++ {
-		db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+		db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormLogger})
 		if err == nil {
 			break
 		}
-		log.Printf("Database connection attempt %d failed: %v", i+1, err)
+		slog.Warn("Database connection attempt failed", "attempt", i+1, "error", err)
 		time.Sleep(2 * time.Second)
 	}
 
 	if err != nil {
-		log.Printf("Failed to connect to database after 30 attempts: %v", err)
-		return nil
-	}
-
-	log.Println("Successfully connected to PostgreSQL database")
-	return db
-}
-
-// CSVからPostgreSQLにポリシーをロード
-func loadInitialPolicies(adapter *gormadapter.Adapter) error {
-	csvPath := "./policy.csv"
-	if _, err := os.Stat("/app/policy.csv"); err == nil {
-		csvPath = "/app/policy.csv"
-	} else if _, err := os.Stat("data/policy.csv"); err == nil {
-		csvPath = "data/policy.csv"
-	}
-
-	if _, err := os.Stat(csvPath); os.IsNotExist(err) {
-		log.Printf("Policy CSV file not found at %s", csvPath)
-		return nil
-	}
-
-	modelPath := "./model.conf"
-	if _, err := os.Stat("/app/model.conf"); err == nil {
-		modelPath = "/app/model.conf"
-	} else if _, err := os.Stat("data/model.conf"); err == nil {
-		modelPath = "data/model.conf"
-	}
-
-	// 一時的にファイルベースのenforcerを作成してポリシーを読み込み
-	tempEnforcer, err := casbin.NewEnforcer(modelPath, csvPath)
-	if err != nil {
-		return fmt.Errorf("failed to create temporary enforcer: %v", err)
-	}
-
-	// 既存のポリシーを削除
-	for _, policy := range enforcer.GetPolicy() {
-		enforcer.RemovePolicy(policy)
-	}
-	for _, grouping := range enforcer.GetGroupingPolicy() {
-		enforcer.RemoveGroupingPolicy(grouping)
+		return nil, fmt.Errorf("failed to connect to database after 30 attempts: %w", err)
 	}
 
-	// ポリシーをデータベースに保存
-	for _, policy := range tempEnforcer.GetPolicy() {
-		enforcer.AddPolicy(policy)
-	}
-
-	for _, grouping := range tempEnforcer.GetGroupingPolicy() {
-		enforcer.AddGroupingPolicy(grouping)
-	}
-
-	// データベースに保存
-	enforcer.SavePolicy()
-
-	log.Println("Initial policies loaded from CSV to PostgreSQL")
-	return nil
+	slog.Info("Successfully connected to PostgreSQL database", "host", cfg.Host, "database", cfg.Database)
+	return db, nil
 }
 
 // CORSミドルウェア
 func enableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// CORSヘッダーを設定
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		// 許可したオリジンのみ返す（認可サーバはサーバ間通信が基本のため "*" にしない）
+		if origin := r.Header.Get("Origin"); origin != "" && isAllowedOrigin(origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		w.Header().Set("Vary", "Origin")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, "+tenantHeader+", "+requestIDHeader)
+		w.Header().Set("Access-Control-Expose-Headers", requestIDHeader)
 		
 		// OPTIONSリクエストの場合はここで終了
 		if r.Method == "OPTIONS" {
@@ -164,177 +128,172 @@ func enableCORS(next http.Handler) http.Handler {
 }
 
 func main() {
-	// Casbinのモデルとポリシーファイルの初期化
-	initializeCasbin()
+	// 設定ファイル・環境変数・フラグから設定を読み込む（誤りがあれば起動しない）
+	cfg, err := loadConfig(os.Args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	// JSON 形式のログ
+	level, _ := parseLogLevel(cfg.LogLevel)
+	initLogger(level)
+
+	// サービス間認証の設定
+	serviceAuth = cfg.Auth
+	allowedOrigins = cfg.CORSAllowedOrigins
+
+	// テナントごとの Casbin のモデルとポリシーの初期化
+	if err := initializeTenants(cfg); err != nil {
+		slog.Error("Failed to initialize Casbin", "error", err)
+		os.Exit(1)
+	}
 
 	router := mux.NewRouter()
 
-	// API endpoints
-	router.HandleFunc("/authorize", authorizeHandler).Methods("POST")
+	// API endpoints（判定・変更系はサービス間認証が必要）
+	router.HandleFunc("/authorize", requireServiceAuth(authorizeHandler)).Methods("POST")
 	router.HandleFunc("/authorize", optionsHandler).Methods("OPTIONS")
+	router.HandleFunc("/authorize/batch", requireServiceAuth(batchAuthorizeHandler)).Methods("POST")
+	router.HandleFunc("/authorize/batch", optionsHandler).Methods("OPTIONS")
 	router.HandleFunc("/policies", getPoliciesHandler).Methods("GET")
-	router.HandleFunc("/policies", addPolicyHandler).Methods("POST")
-	router.HandleFunc("/policies", removePolicyHandler).Methods("DELETE")
+	router.HandleFunc("/policies", requireServiceAuth(addPolicyHandler)).Methods("POST")
+	router.HandleFunc("/policies", requireServiceAuth(removePolicyHandler)).Methods("DELETE")
 	router.HandleFunc("/policies", optionsHandler).Methods("OPTIONS")
-	router.HandleFunc("/groups", getGroupsHandler).Methods("GET")
+	router.HandleFunc("/policies/export", requireServiceAuth(exportPoliciesHandler)).Methods("GET")
+	router.HandleFunc("/policies/export", optionsHandler).Methods("OPTIONS")
+	router.HandleFunc("/policies/import", requireServiceAuth(importPoliciesHandler)).Methods("POST")
+	router.HandleFunc("/policies/import", optionsHandler).Methods("OPTIONS")
+	router.HandleFunc("/groups", requireServiceAuth(getGroupsHandler)).Methods("GET")
 	router.HandleFunc("/groups", optionsHandler).Methods("OPTIONS")
+	router.HandleFunc("/users/{id}/permissions", requireServiceAuth(getUserPermissionsHandler)).Methods("GET")
+	router.HandleFunc("/users/{id}/permissions", optionsHandler).Methods("OPTIONS")
+	router.HandleFunc("/objects/{path:.+}/subjects", requireServiceAuth(getObjectSubjectsHandler)).Methods("GET")
+	router.HandleFunc("/objects/{path:.+}/subjects", optionsHandler).Methods("OPTIONS")
 	router.HandleFunc("/health", healthHandler).Methods("GET")
 	router.HandleFunc("/health", optionsHandler).Methods("OPTIONS")
+	router.HandleFunc("/reload", requireServiceAuth(reloadHandler)).Methods("POST")
+	router.HandleFunc("/reload", optionsHandler).Methods("OPTIONS")
+	router.HandleFunc("/stats", statsHandler).Methods("GET")
+	router.HandleFunc("/stats", optionsHandler).Methods("OPTIONS")
+	router.HandleFunc("/tenants", requireServiceAuth(getTenantsHandler)).Methods("GET")
+	router.HandleFunc("/tenants", optionsHandler).Methods("OPTIONS")
 
 	// ロール管理エンドポイントを追加
 	router.HandleFunc("/user-roles", getUserRolesHandler).Methods("GET")
 	router.HandleFunc("/user-roles", optionsHandler).Methods("OPTIONS")
-	router.HandleFunc("/add-role", addRoleHandler).Methods("POST")
+	router.HandleFunc("/add-role", requireServiceAuth(addRoleHandler)).Methods("POST")
 	router.HandleFunc("/add-role", optionsHandler).Methods("OPTIONS")
-	router.HandleFunc("/remove-role", removeRoleHandler).Methods("POST")
+	router.HandleFunc("/remove-role", requireServiceAuth(removeRoleHandler)).Methods("POST")
 	router.HandleFunc("/remove-role", optionsHandler).Methods("OPTIONS")
 
-	// CORS対応
-	corsHandler := enableCORS(router)
+	// ロールの継承と、継承を含めたロール・権限
+	router.HandleFunc("/role-links", getRoleLinksHandler).Methods("GET")
+	router.HandleFunc("/role-links", requireServiceAuth(addRoleLinkHandler)).Methods("POST")
+	router.HandleFunc("/role-links", requireServiceAuth(removeRoleLinkHandler)).Methods("DELETE")
+	router.HandleFunc("/role-links", optionsHandler).Methods("OPTIONS")
+	router.HandleFunc("/implicit-roles", getImplicitRolesHandler).Methods("GET")
+	router.HandleFunc("/implicit-roles", optionsHandler).Methods("OPTIONS")
+	router.HandleFunc("/implicit-permissions", getImplicitPermissionsHandler).Methods("GET")
+	router.HandleFunc("/implicit-permissions", optionsHandler).Methods("OPTIONS")
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-
-	fmt.Printf("Casbin Authorization Server starting on port %s\n", port)
-	log.Fatal(http.ListenAndServe(":"+port, corsHandler))
-}
+	// リクエスト ID とアクセスログ、CORS対応（/tenants/<テナント>/ の接頭辞は withTenant で取り除く）
+	handler := withRequestID(enableCORS(withTenant(router)))
 
-func initializeCasbin() {
-	usePostgreSQL := os.Getenv("USE_POSTGRES") == "true"
-	
-	if usePostgreSQL {
-		// PostgreSQL使用
-		db := connectToPostgreSQL()
-		if db == nil {
-			log.Println("PostgreSQL connection failed, falling back to file-based storage")
-			usePostgreSQL = false
-		} else {
-			// GORMアダプターを作成
-			adapter, err := gormadapter.NewAdapterByDB(db)
-			if err != nil {
-				log.Printf("Failed to create GORM adapter: %v, falling back to file-based storage", err)
-				usePostgreSQL = false
-			} else {
-				// モデルファイルのパスを指定
-				modelPath := "./model.conf"
-				if _, err := os.Stat("/app/model.conf"); err == nil {
-					modelPath = "/app/model.conf"
-				} else if _, err := os.Stat("data/model.conf"); err == nil {
-					modelPath = "data/model.conf"
-				}
-				
-				// Enforcerを初期化
-				enforcer, err = casbin.NewEnforcer(modelPath, adapter)
-				if err != nil {
-					log.Printf("Failed to create enforcer with PostgreSQL: %v, falling back to file-based storage", err)
-					usePostgreSQL = false
-				} else {
-					// 初回起動時にCSVからポリシーをロード
-					if os.Getenv("LOAD_INITIAL_POLICIES") == "true" {
-						if err := loadInitialPolicies(adapter); err != nil {
-							log.Printf("Warning: Failed to load initial policies: %v", err)
-						}
-					}
-					
-					// ポリシーをロード
-					enforcer.LoadPolicy()
-					log.Println("Casbin enforcer initialized with PostgreSQL adapter")
-				}
-			}
-		}
-	}
-	
-	if !usePostgreSQL {
-		// ファイルベース（既存の実装）
-		modelPath := "./model.conf"
-		policyPath := "./policy.csv"
-		
-		// 本番環境用のパス（Dockerコンテナ内）
-		if _, err := os.Stat("/app/model.conf"); err == nil {
-			modelPath = "/app/model.conf"
-			policyPath = "/app/policy.csv"
-		} else if _, err := os.Stat("data/model.conf"); err == nil {
-			modelPath = "data/model.conf"
-			policyPath = "data/policy.csv"
-		}
-
-		var err error
-		enforcer, err = casbin.NewEnforcer(modelPath, policyPath)
-		if err != nil {
-			log.Fatal("Failed to create enforcer:", err)
-		}
-
-		// ポリシーの自動保存を有効化
-		enforcer.EnableAutoSave(true)
-		log.Println("Casbin enforcer initialized with file-based storage")
-	}
-	
-	// デバッグ: ポリシーとグループポリシーを出力
-	fmt.Println("=== Loaded Policies ===")
-	policies := enforcer.GetPolicy()
-	for _, policy := range policies {
-		fmt.Printf("Policy: %v\n", policy)
-	}
-	
-	fmt.Println("=== Loaded Group Policies ===")
-	groupPolicies := enforcer.GetGroupingPolicy()
-	for _, group := range groupPolicies {
-		fmt.Printf("Group: %v\n", group)
+	if err := runServer(fmt.Sprintf(":%d", cfg.Port), handler); err != nil {
+		slog.Error("Server error", "error", err)
+		os.Exit(1)
 	}
 }
 
 func authorizeHandler(w http.ResponseWriter, r *http.Request) {
+	t := tenantFrom(r)
 	var authReq AuthRequest
 	if err := json.NewDecoder(r.Body).Decode(&authReq); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	fmt.Printf("Authorization request: subject=%s, object=%s, action=%s\n", authReq.Subject, authReq.Object, authReq.Action)
-	
+	domain, err := resolveDomain(authReq.Domain, authReq.Object)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// ユーザーのロール確認
-	roles, _ := enforcer.GetRolesForUser(authReq.Subject)
-	fmt.Printf("User %s has roles: %v\n", authReq.Subject, roles)
+	if slog.Default().Enabled(r.Context(), slog.LevelDebug) {
+		roles, _ := t.Enforcer.GetRolesForUser(authReq.Subject, domain)
+		slog.DebugContext(r.Context(), "User roles", "subject", authReq.Subject, "domain", domain, "roles", roles)
+	}
 
-	allowed, err := enforcer.Enforce(authReq.Subject, authReq.Object, authReq.Action)
+	allowed, matched, err := t.Enforcer.EnforceEx(authReq.Subject, domain, authReq.Object, authReq.Action, requestAttributes(authReq.Attributes))
 	if err != nil {
-		fmt.Printf("Authorization error: %v\n", err)
+		slog.ErrorContext(r.Context(), "Authorization error", "error", err)
 		http.Error(w, "Authorization check failed", http.StatusInternalServerError)
 		return
 	}
 
-	fmt.Printf("Authorization result: %v\n", allowed)
+	logDecision(r, authReq.Subject, domain, authReq.Object, authReq.Action, allowed, matched)
+	t.stats.record(allowed)
 
+	// 判定を決めた p 行を理由として返す
 	response := AuthResponse{
 		Allowed: allowed,
+		Reason:  decisionReason(allowed, matched),
 	}
-
-	if !allowed {
-		response.Reason = "Access denied by policy"
+	if r.URL.Query().Get("explain") == "true" {
+		response.Explanation = t.explain(authReq.Subject, domain, authReq.Object, authReq.Action, allowed, matched)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false) // role_chain の "->" をそのまま返す
+	enc.Encode(response)
 }
 
-func getPoliciesHandler(w http.ResponseWriter, r *http.Request) {
-	policies := enforcer.GetPolicy()
+// 複数の認可チェックを1リクエストで行うハンドラ。結果は requests と同じ順序で返す
+func batchAuthorizeHandler(w http.ResponseWriter, r *http.Request) {
+	t := tenantFrom(r)
+	var batchReq BatchAuthRequest
+	if err := json.NewDecoder(r.Body).Decode(&batchReq); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"policies": policies,
-	})
-}
+	if len(batchReq.Requests) > maxBatchSize {
+		http.Error(w, fmt.Sprintf("Too many requests in batch (max %d)", maxBatchSize), http.StatusBadRequest)
+		return
+	}
 
-func getGroupsHandler(w http.ResponseWriter, r *http.Request) {
-	groupPolicies := enforcer.GetGroupingPolicy()
+	requests := make([][]interface{}, len(batchReq.Requests))
+	for i, authReq := range batchReq.Requests {
+		domain, err := resolveDomain(authReq.Domain, authReq.Object)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("requests[%d]: %v", i, err), http.StatusBadRequest)
+			return
+		}
+		requests[i] = []interface{}{authReq.Subject, domain, authReq.Object, authReq.Action, requestAttributes(authReq.Attributes)}
+	}
+
+	// 一致した p 行を理由として返すため、BatchEnforce ではなく1件ずつ EnforceEx で判定する
+	response := BatchAuthResponse{Results: make([]AuthResponse, len(requests))}
+	for i, rvals := range requests {
+		allowed, matched, err := t.Enforcer.EnforceEx(rvals...)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Batch authorization error", "index", i, "error", err)
+			http.Error(w, "Authorization check failed", http.StatusInternalServerError)
+			return
+		}
+		response.Results[i] = AuthResponse{Allowed: allowed, Reason: decisionReason(allowed, matched)}
+		logDecision(r, rvals[0].(string), rvals[1].(string), rvals[2].(string), rvals[3].(string), allowed, matched)
+		t.stats.record(allowed)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"groups": groupPolicies,
-	})
+	json.NewEncoder(w).Encode(response)
 }
 
 func addPolicyHandler(w http.ResponseWriter, r *http.Request) {
@@ -344,12 +303,13 @@ func addPolicyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(policyReq.Policy) < 3 {
-		http.Error(w, "Policy must have at least 3 elements: subject, object, action", http.StatusBadRequest)
+	policy, err := normalizePolicy(policyReq)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	added, err := enforcer.AddPolicy(policyReq.Policy)
+	added, err := tenantFrom(r).Enforcer.AddPolicy(policy)
 	if err != nil {
 		http.Error(w, "Failed to add policy", http.StatusInternalServerError)
 		return
@@ -358,7 +318,7 @@ func addPolicyHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"added": added,
-		"policy": policyReq.Policy,
+		"policy": policy,
 	})
 }
 
@@ -369,12 +329,13 @@ func removePolicyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(policyReq.Policy) < 3 {
-		http.Error(w, "Policy must have at least 3 elements: subject, object, action", http.StatusBadRequest)
+	policy, err := normalizePolicy(policyReq)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	removed, err := enforcer.RemovePolicy(policyReq.Policy)
+	removed, err := tenantFrom(r).Enforcer.RemovePolicy(policy)
 	if err != nil {
 		http.Error(w, "Failed to remove policy", http.StatusInternalServerError)
 		return
@@ -383,21 +344,19 @@ func removePolicyHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"removed": removed,
-		"policy": policyReq.Policy,
+		"policy": policy,
 	})
 }
 
 func healthHandler(w http.ResponseWriter, r *http.Request) {
-	storage := "file-based"
-	if os.Getenv("USE_POSTGRES") == "true" {
-		storage = "postgresql"
-	}
-	
+	t := tenantFrom(r)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
 		"status": "healthy",
 		"service": "casbin-authorization-server",
-		"storage": storage,
+		"tenant": t.Name,
+		"storage": t.Storage,
 	})
 }
 
@@ -414,22 +373,22 @@ func getUserRolesHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fmt.Printf("Getting roles for user: %s\n", user)
-
-	roles, err := enforcer.GetRolesForUser(user)
-	if err != nil {
-		fmt.Printf("Error getting roles for user %s: %v\n", user, err)
-		http.Error(w, "Failed to get user roles", http.StatusInternalServerError)
-		return
-	}
-
-	fmt.Printf("User %s has roles: %v\n", user, roles)
-
+	// g = ユーザー, ロール, ドメイン
 	response := UserRolesResponse{
-		User:  user,
-		Roles: roles,
+		User:        user,
+		Roles:       []string{},
+		DomainRoles: []DomainRole{},
+	}
+	for _, g := range tenantFrom(r).Enforcer.GetFilteredGroupingPolicy(0, user) {
+		if len(g) < 3 {
+			continue
+		}
+		response.Roles = append(response.Roles, legacyRole(g[1], g[2]))
+		response.DomainRoles = append(response.DomainRoles, DomainRole{Role: g[1], Domain: g[2]})
 	}
 
+	slog.DebugContext(r.Context(), "User roles", "subject", user, "roles", response.Roles)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
@@ -452,7 +411,12 @@ func addRoleHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	added, err := enforcer.AddRoleForUser(roleReq.User, roleReq.Role)
+	role, domain := roleReq.Role, roleReq.Domain
+	if domain == "" {
+		role, domain = parseLegacyRole(roleReq.Role)
+	}
+
+	added, err := tenantFrom(r).Enforcer.AddRoleForUserInDomain(roleReq.User, role, domain)
 	if err != nil {
 		http.Error(w, "Failed to add role", http.StatusInternalServerError)
 		return
@@ -460,9 +424,10 @@ func addRoleHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"added": added,
-		"user":  roleReq.User,
-		"role":  roleReq.Role,
+		"added":  added,
+		"user":   roleReq.User,
+		"role":   role,
+		"domain": domain,
 	})
 }
 
@@ -484,7 +449,12 @@ func removeRoleHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	removed, err := enforcer.DeleteRoleForUser(roleReq.User, roleReq.Role)
+	role, domain := roleReq.Role, roleReq.Domain
+	if domain == "" {
+		role, domain = parseLegacyRole(roleReq.Role)
+	}
+
+	removed, err := tenantFrom(r).Enforcer.DeleteRoleForUserInDomain(roleReq.User, role, domain)
 	if err != nil {
 		http.Error(w, "Failed to remove role", http.StatusInternalServerError)
 		return
@@ -494,6 +464,7 @@ func removeRoleHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"removed": removed,
 		"user":    roleReq.User,
-		"role":    roleReq.Role,
+		"role":    role,
+		"domain":  domain,
 	})
 } 
\ No newline at end of file
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/casbin/casbin/v2/util"
+	gormadapter "github.com/casbin/gorm-adapter/v3"
+	"github.com/gorilla/mux"
+)
+
+// 一覧の limit の最大値（limit を省略した場合はページに分けずに全件返す）
+const maxPageSize = 1000
+
+// ruleFilter は p / g 行の絞り込み条件。
+// exact は列ごとの完全一致（空文字列は条件なし）で、GetFilteredPolicy と DB の絞り込みに使う
+type ruleFilter struct {
+	exact        []string
+	objectPrefix string
+}
+
+func (f ruleFilter) match(rule []string) bool {
+	return f.objectPrefix == "" || (len(rule) > 2 && strings.HasPrefix(rule[2], f.objectPrefix))
+}
+
+// queryRules は sec（p / g）の行を絞り込んで返す。
+// PostgreSQL の場合は完全一致の条件を DB の絞り込み読み込みで適用し、他のインスタンスの変更を含めた DB の内容を返す
+func (t *Tenant) queryRules(sec string, filter ruleFilter) ([][]string, error) {
+	var rules [][]string
+	if t.queryAdapter != nil {
+		t.Enforcer.GetLock().RLock()
+		m := t.Enforcer.GetModel().Copy()
+		t.Enforcer.GetLock().RUnlock()
+		m.ClearPolicy()
+		dbFilter := gormadapter.Filter{Ptype: []string{sec}}
+		columns := []*[]string{&dbFilter.V0, &dbFilter.V1, &dbFilter.V2, &dbFilter.V3, &dbFilter.V4, &dbFilter.V5}
+		for i, value := range filter.exact {
+			if value != "" {
+				*columns[i] = []string{value}
+			}
+		}
+		if err := t.queryAdapter.LoadFilteredPolicy(m, dbFilter); err != nil {
+			return nil, fmt.Errorf("failed to load filtered policy: %w", err)
+		}
+		rules = m.GetPolicy(sec, sec)
+	} else if sec == "p" {
+		rules = t.Enforcer.GetFilteredPolicy(0, filter.exact...)
+	} else {
+		rules = t.Enforcer.GetFilteredGroupingPolicy(0, filter.exact...)
+	}
+
+	result := [][]string{}
+	for _, rule := range rules {
+		if filter.match(rule) {
+			result = append(result, rule)
+		}
+	}
+	return result, nil
+}
+
+// page はソートした rules から cursor の次の行を最大 limit 件返す。続きがあれば次の cursor も返す。
+// cursor は前のページの最後の行のため、ページの間に行が追加・削除されても重複や抜けが起きない
+func page(rules [][]string, r *http.Request) ([][]string, string, error) {
+	query := r.URL.Query()
+	limit := 0
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxPageSize {
+			return nil, "", fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+		}
+		limit = n
+	}
+
+	sort.Slice(rules, func(i, j int) bool { return compareRules(rules[i], rules[j]) < 0 })
+	start := 0
+	if cursor := query.Get("cursor"); cursor != "" {
+		after, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		start = sort.Search(len(rules), func(i int) bool { return compareRules(rules[i], after) > 0 })
+	}
+
+	rules = rules[start:]
+	if limit == 0 || len(rules) <= limit {
+		return rules, "", nil
+	}
+	return rules[:limit], encodeCursor(rules[limit-1]), nil
+}
+
+func compareRules(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := strings.Compare(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return len(a) - len(b)
+}
+
+func encodeCursor(rule []string) string {
+	data, _ := json.Marshal(rule)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor string) ([]string, error) {
+	var rule []string
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || json.Unmarshal(data, &rule) != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return rule, nil
+}
+
+// writeRules は絞り込んだ行を1ページ分返す
+func writeRules(w http.ResponseWriter, r *http.Request, key string, sec string, filter ruleFilter) {
+	rules, err := tenantFrom(r).queryRules(sec, filter)
+	if err != nil {
+		http.Error(w, "Failed to query "+key+": "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rules, next, err := page(rules, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := map[string]interface{}{key: rules}
+	if next != "" {
+		response["next_cursor"] = next
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ポリシー（p 行）の一覧を取得するハンドラ。
+// subject / domain / action / eft は完全一致、object_prefix は object の前方一致で絞り込む
+func getPoliciesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	writeRules(w, r, "policies", "p", ruleFilter{
+		exact:        []string{query.Get("subject"), query.Get("domain"), "", query.Get("action"), query.Get("eft")},
+		objectPrefix: query.Get("object_prefix"),
+	})
+}
+
+// ロール割り当て・継承（g 行）の一覧を取得するハンドラ。subject / role / domain の完全一致で絞り込む
+func getGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	writeRules(w, r, "groups", "g", ruleFilter{
+		exact: []string{query.Get("subject"), query.Get("role"), query.Get("domain")},
+	})
+}
+
+type UserPermission struct {
+	Domain string   `json:"domain"`
+	Policy []string `json:"policy"`
+}
+
+// ユーザーが（継承したロールと global のロールを含めて）持つ権限を取得するハンドラ。
+// domain を省略した場合はロールが割り当てられているドメインと global のすべて。action / object_prefix でも絞り込める
+func getUserPermissionsHandler(w http.ResponseWriter, r *http.Request) {
+	t := tenantFrom(r)
+	user := mux.Vars(r)["id"]
+	query := r.URL.Query()
+
+	domains := t.userDomains(r, user)
+	if query.Get("domain") == "" && !containsString(domains, globalDomain) {
+		domains = append(domains, globalDomain)
+	}
+	filter := ruleFilter{objectPrefix: query.Get("object_prefix")}
+
+	permissions := []UserPermission{}
+	for _, domain := range domains {
+		policies, err := t.implicitPermissions(user, domain)
+		if err != nil {
+			http.Error(w, "Failed to get permissions: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, p := range policies {
+			if action := query.Get("action"); action != "" && len(p) > 3 && p[3] != action && p[3] != "*" {
+				continue
+			}
+			if filter.match(p) {
+				permissions = append(permissions, UserPermission{Domain: domain, Policy: p})
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user":        user,
+		"permissions": permissions,
+	})
+}
+
+type ObjectSubject struct {
+	Subject string   `json:"subject"`
+	Actions []string `json:"actions"`
+}
+
+// オブジェクトにアクセスできるユーザーと操作を取得するハンドラ（例: /objects/system/system1/subjects?action=PUT）。
+// 一致する p 行の操作について、ユーザーごとに /authorize と同じ判定（属性は既定値）を行うため、拒否ルールも反映される
+func getObjectSubjectsHandler(w http.ResponseWriter, r *http.Request) {
+	t := tenantFrom(r)
+	object := "/" + mux.Vars(r)["path"]
+	query := r.URL.Query()
+	domain, err := resolveDomain(query.Get("domain"), object)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// 判定する操作は action、省略時は object とドメインに一致する allow の p 行の操作
+	actions := []string{query.Get("action")}
+	if actions[0] == "" {
+		actions = t.objectActions(domain, object)
+	}
+
+	subjects := []ObjectSubject{}
+	attrs := requestAttributes(nil)
+	for _, user := range t.knownUsers() {
+		var allowed []string
+		for _, action := range actions {
+			ok, err := t.Enforcer.Enforce(user, domain, object, action, attrs)
+			if err != nil {
+				http.Error(w, "Authorization check failed", http.StatusInternalServerError)
+				return
+			}
+			if ok {
+				allowed = append(allowed, action)
+			}
+		}
+		if len(allowed) > 0 {
+			subjects = append(subjects, ObjectSubject{Subject: user, Actions: allowed})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"object":   object,
+		"domain":   domain,
+		"subjects": subjects,
+	})
+}
+
+// objectActions は domain と object に一致する allow の p 行の操作を返す。"*" は p 行にある具体的な操作に置き換える
+func (t *Tenant) objectActions(domain, object string) []string {
+	actions := map[string]bool{}
+	for _, p := range t.Enforcer.GetFilteredPolicy(effectIndex, effectAllow) {
+		if util.KeyMatch(domain, p[1]) && util.KeyMatch(object, p[2]) {
+			actions[p[3]] = true
+		}
+	}
+	if actions["*"] {
+		delete(actions, "*")
+		for _, p := range t.Enforcer.GetPolicy() {
+			if p[3] != "*" {
+				actions[p[3]] = true
+			}
+		}
+	}
+	return sortedKeys(actions)
+}
+
+// knownUsers は g 行と p 行に現れるユーザー（ロールとして使われている名前を除く）を返す
+func (t *Tenant) knownUsers() []string {
+	roles := map[string]bool{}
+	for _, g := range t.Enforcer.GetGroupingPolicy() {
+		roles[g[1]] = true
+	}
+	users := map[string]bool{}
+	for _, g := range t.Enforcer.GetGroupingPolicy() {
+		if !roles[g[0]] {
+			users[g[0]] = true
+		}
+	}
+	for _, p := range t.Enforcer.GetPolicy() {
+		if !roles[p[0]] {
+			users[p[0]] = true
+		}
+	}
+	return sortedKeys(users)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// ロールの継承（ロール → 継承元のロール）。g 行のうちドメインがパターン（system:* / *）のもの。
+// g のドメインは keyMatch で比較するため、パターンに一致するすべてのドメインで継承が有効になる
+type RoleLinkRequest struct {
+	Role     string `json:"role"`
+	Inherits string `json:"inherits"`
+	Domain   string `json:"domain"`
+}
+
+type DomainRoles struct {
+	Domain string   `json:"domain"`
+	Roles  []string `json:"roles"`
+}
+
+type DomainPermissions struct {
+	Domain      string     `json:"domain"`
+	Permissions [][]string `json:"permissions"`
+}
+
+// isDomainPattern はロールの継承に使うドメインのパターンかどうか
+func isDomainPattern(domain string) bool {
+	return strings.Contains(domain, "*")
+}
+
+// ロールの継承一覧を取得するハンドラ
+func getRoleLinksHandler(w http.ResponseWriter, r *http.Request) {
+	t := tenantFrom(r)
+	links := []RoleLinkRequest{}
+	for _, g := range t.Enforcer.GetGroupingPolicy() {
+		if len(g) >= 3 && isDomainPattern(g[2]) {
+			links = append(links, RoleLinkRequest{Role: g[0], Inherits: g[1], Domain: g[2]})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"links": links,
+	})
+}
+
+func decodeRoleLink(w http.ResponseWriter, r *http.Request) (RoleLinkRequest, bool) {
+	var req RoleLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return req, false
+	}
+	if req.Role == "" || req.Inherits == "" || req.Domain == "" {
+		http.Error(w, "role, inherits and domain are required", http.StatusBadRequest)
+		return req, false
+	}
+	if !isDomainPattern(req.Domain) {
+		http.Error(w, "domain must be a pattern such as system:* or *", http.StatusBadRequest)
+		return req, false
+	}
+	return req, true
+}
+
+// ロールの継承を追加するハンドラ
+func addRoleLinkHandler(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeRoleLink(w, r)
+	if !ok {
+		return
+	}
+
+	added, err := tenantFrom(r).Enforcer.AddGroupingPolicy(req.Role, req.Inherits, req.Domain)
+	if err != nil {
+		http.Error(w, "Failed to add role link", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"added": added,
+		"link":  req,
+	})
+}
+
+// ロールの継承を削除するハンドラ
+func removeRoleLinkHandler(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeRoleLink(w, r)
+	if !ok {
+		return
+	}
+
+	removed, err := tenantFrom(r).Enforcer.RemoveGroupingPolicy(req.Role, req.Inherits, req.Domain)
+	if err != nil {
+		http.Error(w, "Failed to remove role link", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"removed": removed,
+		"link":    req,
+	})
+}
+
+// userDomains は domain クエリパラメータのドメイン、省略時はユーザーにロールが割り当てられているドメインを返す
+func (t *Tenant) userDomains(r *http.Request, user string) []string {
+	if domain := r.URL.Query().Get("domain"); domain != "" {
+		return []string{domain}
+	}
+	seen := map[string]bool{}
+	var domains []string
+	for _, g := range t.Enforcer.GetFilteredGroupingPolicy(0, user) {
+		if len(g) >= 3 && !seen[g[2]] {
+			seen[g[2]] = true
+			domains = append(domains, g[2])
+		}
+	}
+	return domains
+}
+
+// implicitRoles はドメインでユーザーが（継承を含めて）持つロールを返す。
+// マッチャーと同じく globalDomain のロールも含める
+func (t *Tenant) implicitRoles(user, domain string) ([]string, error) {
+	roles, err := t.Enforcer.GetImplicitRolesForUser(user, domain)
+	if err != nil || domain == globalDomain {
+		return roles, err
+	}
+
+	globalRoles, err := t.Enforcer.GetRolesForUser(user, globalDomain)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, role := range roles {
+		seen[role] = true
+	}
+	for _, globalRole := range globalRoles {
+		inherited, err := t.Enforcer.GetImplicitRolesForUser(globalRole, domain)
+		if err != nil {
+			return nil, err
+		}
+		for _, role := range append([]string{globalRole}, inherited...) {
+			if !seen[role] {
+				seen[role] = true
+				roles = append(roles, role)
+			}
+		}
+	}
+	return roles, nil
+}
+
+// implicitPermissions はドメインでユーザーが（継承したロールを含めて）持つ p 行を返す
+func (t *Tenant) implicitPermissions(user, domain string) ([][]string, error) {
+	permissions, err := t.Enforcer.GetImplicitPermissionsForUser(user, domain)
+	if err != nil || domain == globalDomain {
+		return permissions, err
+	}
+
+	globalRoles, err := t.Enforcer.GetRolesForUser(user, globalDomain)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, p := range permissions {
+		seen[strings.Join(p, ", ")] = true
+	}
+	for _, globalRole := range globalRoles {
+		inherited, err := t.Enforcer.GetImplicitPermissionsForUser(globalRole, domain)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range inherited {
+			if key := strings.Join(p, ", "); !seen[key] {
+				seen[key] = true
+				permissions = append(permissions, p)
+			}
+		}
+	}
+	return permissions, nil
+}
+
+// ユーザーが継承を含めて持つロールを取得するハンドラ（domain 省略時は割り当てのあるドメインごと）
+func getImplicitRolesHandler(w http.ResponseWriter, r *http.Request) {
+	t := tenantFrom(r)
+	user := r.URL.Query().Get("user")
+	if user == "" {
+		http.Error(w, "User parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	results := []DomainRoles{}
+	for _, domain := range t.userDomains(r, user) {
+		roles, err := t.implicitRoles(user, domain)
+		if err != nil {
+			http.Error(w, "Failed to get implicit roles: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		results = append(results, DomainRoles{Domain: domain, Roles: roles})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user":    user,
+		"results": results,
+	})
+}
+
+// ユーザーが継承を含めて持つ権限（p 行）を取得するハンドラ（domain 省略時は割り当てのあるドメインごと）
+func getImplicitPermissionsHandler(w http.ResponseWriter, r *http.Request) {
+	t := tenantFrom(r)
+	user := r.URL.Query().Get("user")
+	if user == "" {
+		http.Error(w, "User parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	results := []DomainPermissions{}
+	for _, domain := range t.userDomains(r, user) {
+		permissions, err := t.implicitPermissions(user, domain)
+		if err != nil {
+			http.Error(w, "Failed to get implicit permissions: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		results = append(results, DomainPermissions{Domain: domain, Permissions: permissions})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user":    user,
+		"results": results,
+	})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// サービス間認証の設定。preshared key と mTLS クライアント証明書のどちらか（または両方）を使う
+type serviceAuthConfig struct {
+	PresharedKey string `yaml:"preshared_key"`
+	CertFile     string `yaml:"tls_cert_file"`
+	KeyFile      string `yaml:"tls_key_file"`
+	ClientCAFile string `yaml:"tls_client_ca_file"`
+}
+
+var serviceAuth serviceAuthConfig
+
+// CORSで許可するオリジン（Config.CORSAllowedOrigins）
+var allowedOrigins []string
+
+// validate はサービス間認証の設定を検証する
+func (cfg serviceAuthConfig) validate() error {
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return fmt.Errorf("auth.tls_cert_file (CASBIN_TLS_CERT_FILE) and auth.tls_key_file (CASBIN_TLS_KEY_FILE) must be set together")
+	}
+	if cfg.ClientCAFile != "" && cfg.CertFile == "" {
+		return fmt.Errorf("auth.tls_client_ca_file (CASBIN_TLS_CLIENT_CA_FILE) requires auth.tls_cert_file and auth.tls_key_file")
+	}
+	if cfg.PresharedKey == "" && cfg.ClientCAFile == "" {
+		return fmt.Errorf("auth.preshared_key (CASBIN_PRESHARED_KEY) or auth.tls_client_ca_file (CASBIN_TLS_CLIENT_CA_FILE) is required")
+	}
+	return nil
+}
+
+// requireServiceAuth は preshared key または検証済みのクライアント証明書を持つリクエストのみ通す
+func requireServiceAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if serviceAuth.authenticated(r) {
+			next(w, r)
+			return
+		}
+
+		slog.WarnContext(r.Context(), "Service authentication failed", "method", r.Method, "path", r.URL.Path, "remote_addr", r.RemoteAddr)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "service authentication required",
+		})
+	}
+}
+
+func (cfg serviceAuthConfig) authenticated(r *http.Request) bool {
+	// mTLS: ClientCAs で検証済みの証明書があれば認証済みとする
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		return true
+	}
+
+	if cfg.PresharedKey == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(cfg.PresharedKey)) == 1
+}
+
+// serve は TLS 証明書が設定されていれば HTTPS（クライアント証明書の検証付き）で、なければ HTTP で待ち受ける
+func (cfg serviceAuthConfig) serve(server *http.Server) error {
+	if cfg.CertFile == "" {
+		return server.ListenAndServe()
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.ClientCAFile != "" {
+		caPEM, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return fmt.Errorf("no certificates found in %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		// preshared key のクライアントも受け付けるため、証明書は提示された場合のみ検証する
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	server.TLSConfig = tlsConfig
+	return server.ListenAndServeTLS(cfg.CertFile, cfg.KeyFile)
+}
+
+func isAllowedOrigin(origin string) bool {
+	for _, allowed := range allowedOrigins {
+		if strings.TrimSpace(allowed) == origin {
+			return true
+		}
+	}
+	return false
+}
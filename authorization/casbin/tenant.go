@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/util"
+	gormadapter "github.com/casbin/gorm-adapter/v3"
+	"gorm.io/gorm"
+)
+
+// テナントを指定しない場合のテナント。既存の model.conf / policy.csv と casbin_rule テーブルを使う
+const defaultTenant = "default"
+
+// テナントを指定するヘッダー（パスの接頭辞 /tenants/<テナント>/ でも指定できる）
+const tenantHeader = "X-Casbin-Tenant"
+
+// テナント名はテーブル名と通知のチャネル名に使うため、英小文字・数字・_ のみ
+var tenantNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Tenant は事業部ごとのモデルとポリシー。Enforcer・アダプター（テーブル）・Watcher（チャネル）をテナントごとに分ける
+type Tenant struct {
+	Name     string
+	Enforcer *casbin.SyncedEnforcer
+	// postgresql / file-based
+	Storage string
+
+	// PostgreSQL の場合のみ
+	adapter      *gormadapter.Adapter // インポートでトランザクションを張る DB を取得する
+	queryAdapter *gormadapter.Adapter // 一覧を DB から絞り込んで読み込む（enforcer のアダプターを絞り込み読み込みにすると SavePolicy ができなくなるため別に作る）
+	watcher      *PostgresWatcher
+
+	importMu sync.Mutex // インポートを直列化する
+
+	stats tenantStats
+}
+
+// tenantStats はテナントごとの判定件数
+type tenantStats struct {
+	startedAt  time.Time
+	lastReload atomic.Int64 // UnixNano
+	allowed    atomic.Int64
+	denied     atomic.Int64
+}
+
+func (s *tenantStats) record(allowed bool) {
+	if allowed {
+		s.allowed.Add(1)
+	} else {
+		s.denied.Add(1)
+	}
+}
+
+// tenants は起動時に作成し、以降は変更しない
+var tenants = map[string]*Tenant{}
+
+// initializeTenants は既定のテナントと cfg.Tenants のテナントを作成する。
+// PostgreSQL を使えない場合は、storage.allow_file_fallback が true の場合のみファイルで起動する
+func initializeTenants(cfg *Config) error {
+	var db *gorm.DB
+	if cfg.Storage.Type == storagePostgres {
+		// PostgreSQL使用
+		var err error
+		db, err = connectToPostgreSQL(cfg.Postgres)
+		if err != nil {
+			if !cfg.Storage.AllowFileFallback {
+				return err
+			}
+			slog.Warn("PostgreSQL connection failed, falling back to file-based storage", "error", err)
+		}
+	}
+
+	for _, name := range append([]string{defaultTenant}, cfg.Tenants...) {
+		t, err := newTenant(cfg, name, db)
+		if err != nil {
+			return fmt.Errorf("failed to initialize tenant %s: %w", name, err)
+		}
+		tenants[name] = t
+		slog.Info("Tenant initialized", "tenant", name, "storage", t.Storage,
+			"policies", len(t.Enforcer.GetPolicy()), "groups", len(t.Enforcer.GetGroupingPolicy()))
+	}
+	return nil
+}
+
+// closeTenants はテナントの Watcher（LISTEN）を止める
+func closeTenants() {
+	for _, t := range tenants {
+		if t.watcher != nil {
+			t.watcher.Close()
+		}
+	}
+}
+
+// tenantTable はテナントのポリシーを保存する gorm-adapter のテーブル
+func tenantTable(name string) string {
+	if name == defaultTenant {
+		return "casbin_rule"
+	}
+	return "casbin_rule_" + name
+}
+
+// tenantChannel はテナントのポリシーの変更を通知するチャネル
+func tenantChannel(name string) string {
+	if name == defaultTenant {
+		return policyChannel
+	}
+	return policyChannel + "_" + name
+}
+
+// newTenant はテナントの Enforcer を作成する。db が nil の場合はファイルを使う
+func newTenant(cfg *Config, name string, db *gorm.DB) (*Tenant, error) {
+	modelPath, policyPath := cfg.tenantPaths(name)
+	t := &Tenant{Name: name}
+	t.stats.startedAt = time.Now()
+
+	if db != nil {
+		if err := t.initializePostgres(cfg, db, modelPath, policyPath); err != nil {
+			if !cfg.Storage.AllowFileFallback {
+				return nil, err
+			}
+			slog.Warn("Falling back to file-based storage", "tenant", name, "error", err)
+			t = &Tenant{Name: name, stats: tenantStats{startedAt: t.stats.startedAt}}
+		}
+	}
+
+	if t.Enforcer == nil {
+		// ファイルベース（既存の実装）
+		var err error
+		t.Enforcer, err = casbin.NewSyncedEnforcer(modelPath, policyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create enforcer: %w", err)
+		}
+
+		// ポリシーの自動保存を有効化
+		t.Enforcer.EnableAutoSave(true)
+		t.Storage = "file-based"
+	}
+
+	// g のドメインを keyMatch で比較し、ドメインのパターン（system:*）に対するロールの継承を有効にする
+	t.Enforcer.AddNamedDomainMatchingFunc("g", "keyMatch", util.KeyMatch)
+	// p 行の cond（ABAC の条件）で使う関数
+	registerConditionFunctions(t.Enforcer)
+	t.stats.lastReload.Store(time.Now().UnixNano())
+	return t, nil
+}
+
+func (t *Tenant) initializePostgres(cfg *Config, db *gorm.DB, modelPath, policyPath string) error {
+	// GORMアダプターを作成
+	adapter, err := gormadapter.NewAdapterByDBUseTableName(db, "", tenantTable(t.Name))
+	if err != nil {
+		return fmt.Errorf("failed to create GORM adapter: %w", err)
+	}
+
+	// Enforcerを初期化
+	t.Enforcer, err = casbin.NewSyncedEnforcer(modelPath, adapter)
+	if err != nil {
+		return fmt.Errorf("failed to create enforcer with PostgreSQL: %w", err)
+	}
+	t.adapter = adapter
+	t.Storage = "postgresql"
+
+	// 初回起動時にCSVからポリシーをロード
+	loadInitial := cfg.Storage.LoadInitialPolicies
+	if loadInitial {
+		if err := t.loadInitialPolicies(policyPath); err != nil {
+			slog.Warn("Failed to load initial policies", "tenant", t.Name, "error", err)
+		}
+	}
+
+	// ポリシーをロード（失敗した場合は空のポリシーですべて拒否しないよう起動を止めるか、ファイルに切り替える）
+	if err := t.Enforcer.LoadPolicy(); err != nil {
+		return fmt.Errorf("failed to load policies: %w", err)
+	}
+
+	// 他のインスタンスとポリシーの変更を同期する（LISTEN / NOTIFY）
+	t.watcher = NewPostgresWatcher(db, cfg.Postgres.dsn(), tenantChannel(t.Name))
+	t.Enforcer.SetWatcher(t.watcher)
+	t.watcher.SetUpdateCallback(t.applyPolicyUpdate)
+
+	// 一覧の取得は DB から絞り込んで読み込む
+	t.queryAdapter, err = gormadapter.NewFilteredAdapterByDB(db, "", tenantTable(t.Name))
+	if err != nil {
+		slog.Warn("Failed to create filtered adapter, listing from memory", "tenant", t.Name, "error", err)
+	}
+	if loadInitial {
+		// CSV から読み込み直したポリシーを起動済みのインスタンスにも反映する
+		if err := t.watcher.Update(); err != nil {
+			slog.Warn("Failed to notify initial policies", "tenant", t.Name, "error", err)
+		}
+	}
+	return nil
+}
+
+// loadInitialPolicies は policy.csv の内容を DB に読み込む。
+// DB の内容との差分を1つのトランザクションで適用する（/policies/import の mode=replace と同じ）
+func (t *Tenant) loadInitialPolicies(csvPath string) error {
+	file, err := os.Open(csvPath)
+	if os.IsNotExist(err) {
+		slog.Warn("Policy CSV file not found", "tenant", t.Name, "path", csvPath)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open policy CSV: %w", err)
+	}
+	defer file.Close()
+
+	set, err := parsePolicyCSV(file)
+	if err != nil {
+		return fmt.Errorf("failed to parse policy CSV: %w", err)
+	}
+	if err := normalizePolicySet(&set); err != nil {
+		return fmt.Errorf("invalid policy CSV: %w", err)
+	}
+
+	added, removed, err := t.importPolicies(set, true, false)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Initial policies loaded from CSV to PostgreSQL", "tenant", t.Name,
+		"added_policies", len(added.Policies), "removed_policies", len(removed.Policies),
+		"added_groups", len(added.Groups), "removed_groups", len(removed.Groups))
+	return nil
+}
+
+type tenantContextKey struct{}
+
+// withTenant はパスの接頭辞 /tenants/<テナント>/ または X-Casbin-Tenant ヘッダーからテナントを選ぶ。
+// 接頭辞は取り除いてから次のハンドラに渡すため、すべてのエンドポイントをテナントごとに使える
+func withTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.Header.Get(tenantHeader)
+		if rest, ok := strings.CutPrefix(r.URL.Path, "/tenants/"); ok {
+			prefixed, path, found := strings.Cut(rest, "/")
+			if !found {
+				http.NotFound(w, r)
+				return
+			}
+			if name != "" && name != prefixed {
+				http.Error(w, fmt.Sprintf("tenant in path (%s) does not match %s header (%s)", prefixed, tenantHeader, name), http.StatusBadRequest)
+				return
+			}
+			name = prefixed
+
+			r = r.Clone(r.Context())
+			r.URL.Path = "/" + path
+			r.URL.RawPath = ""
+		}
+		if name == "" {
+			name = defaultTenant
+		}
+
+		t, ok := tenants[name]
+		if !ok {
+			http.Error(w, "Unknown tenant: "+name, http.StatusNotFound)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tenantContextKey{}, t)))
+	})
+}
+
+// tenantFrom は withTenant が選んだテナントを返す
+func tenantFrom(r *http.Request) *Tenant {
+	if t, ok := r.Context().Value(tenantContextKey{}).(*Tenant); ok {
+		return t
+	}
+	return tenants[defaultTenant]
+}
+
+// TenantStats はテナントの統計情報
+type TenantStats struct {
+	Tenant     string    `json:"tenant"`
+	Storage    string    `json:"storage"`
+	Policies   int       `json:"policies"`
+	Groups     int       `json:"groups"`
+	Allowed    int64     `json:"allowed"`
+	Denied     int64     `json:"denied"`
+	StartedAt  time.Time `json:"started_at"`
+	LastReload time.Time `json:"last_reload"`
+}
+
+func (t *Tenant) statistics() TenantStats {
+	return TenantStats{
+		Tenant:     t.Name,
+		Storage:    t.Storage,
+		Policies:   len(t.Enforcer.GetPolicy()),
+		Groups:     len(t.Enforcer.GetGroupingPolicy()),
+		Allowed:    t.stats.allowed.Load(),
+		Denied:     t.stats.denied.Load(),
+		StartedAt:  t.stats.startedAt,
+		LastReload: time.Unix(0, t.stats.lastReload.Load()),
+	}
+}
+
+// テナントのポリシー件数と判定件数を取得するハンドラ
+func statsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tenantFrom(r).statistics())
+}
+
+// テナントの一覧と統計情報を取得するハンドラ
+func getTenantsHandler(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(tenants))
+	for name := range tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]TenantStats, 0, len(names))
+	for _, name := range names {
+		results = append(results, tenants[name].statistics())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"tenants": results,
+	})
+}
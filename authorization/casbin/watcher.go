@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/casbin/casbin/v2/model"
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// ポリシーの変更を通知する Postgres のチャネル（既定のテナント。他のテナントは末尾に _<テナント> を付ける）
+const policyChannel = "casbin_policy_updates"
+
+// NOTIFY のペイロードの上限（8000 バイト）を超える変更は、差分ではなく再読み込みとして通知する
+const maxNotifyPayload = 7900
+
+// 通知の種類
+const (
+	updateAdd            = "add"
+	updateRemove         = "remove"
+	updateRemoveFiltered = "remove_filtered"
+	updateReload         = "reload"
+)
+
+// LISTEN の接続が切れた場合に再接続するまでの間隔
+const listenRetryInterval = 5 * time.Second
+
+// policyUpdate はインスタンス間で通知するポリシーの変更
+type policyUpdate struct {
+	// 通知元のインスタンス（自分の通知は無視する）
+	Instance    string     `json:"instance"`
+	Op          string     `json:"op"`
+	Sec         string     `json:"sec,omitempty"`
+	Ptype       string     `json:"ptype,omitempty"`
+	Rules       [][]string `json:"rules,omitempty"`
+	FieldIndex  int        `json:"field_index,omitempty"`
+	FieldValues []string   `json:"field_values,omitempty"`
+}
+
+// PostgresWatcher は Postgres の LISTEN / NOTIFY でポリシーの変更をインスタンス間に通知する Casbin の Watcher（WatcherEx）。
+// enforcer.AddPolicy などで変更すると差分を NOTIFY し、他のインスタンスは差分を Self* で反映する（アダプターには書き込まない）
+type PostgresWatcher struct {
+	db       *gorm.DB
+	dsn      string
+	channel  string
+	instance string
+	cancel   context.CancelFunc
+
+	mu       sync.Mutex
+	callback func(string)
+}
+
+// NewPostgresWatcher は channel を LISTEN する。通知の反映は SetUpdateCallback で設定する
+func NewPostgresWatcher(db *gorm.DB, dsn, channel string) *PostgresWatcher {
+	hostname, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &PostgresWatcher{
+		db:       db,
+		dsn:      dsn,
+		channel:  channel,
+		instance: fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().UnixNano()),
+		callback: func(string) {},
+		cancel:   cancel,
+	}
+	go w.listen(ctx)
+	return w
+}
+
+// listen は通知を受け取り callback に渡す。接続が切れた場合は再接続し、
+// 切れている間の通知を取りこぼしている可能性があるため再読み込みする
+func (w *PostgresWatcher) listen(ctx context.Context) {
+	for reconnect := false; ; reconnect = true {
+		if err := w.listenOnce(ctx, reconnect); err != nil {
+			slog.Warn("Policy watcher", "channel", w.channel, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryInterval):
+		}
+	}
+}
+
+func (w *PostgresWatcher) listenOnce(ctx context.Context, reconnect bool) error {
+	conn, err := pgx.Connect(ctx, w.dsn)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+w.channel); err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	slog.Info("Policy watcher listening", "channel", w.channel, "instance", w.instance)
+	if reconnect {
+		data, _ := json.Marshal(policyUpdate{Op: updateReload})
+		w.runCallback(string(data))
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to wait for notification: %w", err)
+		}
+		w.runCallback(notification.Payload)
+	}
+}
+
+func (w *PostgresWatcher) runCallback(payload string) {
+	w.mu.Lock()
+	callback := w.callback
+	w.mu.Unlock()
+	callback(payload)
+}
+
+// payload は自分のインスタンス ID を付けた通知の JSON
+func (w *PostgresWatcher) payload(update policyUpdate) string {
+	update.Instance = w.instance
+	data, _ := json.Marshal(update)
+	return string(data)
+}
+
+func (w *PostgresWatcher) notify(update policyUpdate) error {
+	payload := w.payload(update)
+	if len(payload) > maxNotifyPayload {
+		payload = w.payload(policyUpdate{Op: updateReload})
+	}
+	if err := w.db.Exec("SELECT pg_notify(?, ?)", w.channel, payload).Error; err != nil {
+		return fmt.Errorf("failed to notify policy update: %w", err)
+	}
+	return nil
+}
+
+// SetUpdateCallback は通知を受け取ったときに呼ぶ関数を設定する（persist.Watcher）
+func (w *PostgresWatcher) SetUpdateCallback(callback func(string)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callback = callback
+	return nil
+}
+
+// Update は他のインスタンスにポリシーの再読み込みを通知する（persist.Watcher）
+func (w *PostgresWatcher) Update() error {
+	return w.notify(policyUpdate{Op: updateReload})
+}
+
+// Close は LISTEN を止める（persist.Watcher）
+func (w *PostgresWatcher) Close() {
+	w.cancel()
+}
+
+func (w *PostgresWatcher) UpdateForAddPolicy(sec, ptype string, params ...string) error {
+	return w.notify(policyUpdate{Op: updateAdd, Sec: sec, Ptype: ptype, Rules: [][]string{params}})
+}
+
+func (w *PostgresWatcher) UpdateForRemovePolicy(sec, ptype string, params ...string) error {
+	return w.notify(policyUpdate{Op: updateRemove, Sec: sec, Ptype: ptype, Rules: [][]string{params}})
+}
+
+func (w *PostgresWatcher) UpdateForRemoveFilteredPolicy(sec, ptype string, fieldIndex int, fieldValues ...string) error {
+	return w.notify(policyUpdate{Op: updateRemoveFiltered, Sec: sec, Ptype: ptype, FieldIndex: fieldIndex, FieldValues: fieldValues})
+}
+
+func (w *PostgresWatcher) UpdateForSavePolicy(model model.Model) error {
+	return w.notify(policyUpdate{Op: updateReload})
+}
+
+func (w *PostgresWatcher) UpdateForAddPolicies(sec string, ptype string, rules ...[]string) error {
+	return w.notify(policyUpdate{Op: updateAdd, Sec: sec, Ptype: ptype, Rules: rules})
+}
+
+func (w *PostgresWatcher) UpdateForRemovePolicies(sec string, ptype string, rules ...[]string) error {
+	return w.notify(policyUpdate{Op: updateRemove, Sec: sec, Ptype: ptype, Rules: rules})
+}
+
+// applyPolicyUpdate は他のインスタンスからの通知をテナントの Enforcer に反映する。
+// 差分はアダプターに書き込まず（通知元が書き込み済み）、再び通知もしない Self* で反映する
+func (t *Tenant) applyPolicyUpdate(payload string) {
+	var update policyUpdate
+	if err := json.Unmarshal([]byte(payload), &update); err != nil {
+		slog.Warn("Policy watcher: invalid payload", "tenant", t.Name, "payload", payload, "error", err)
+		return
+	}
+	if t.watcher != nil && update.Instance == t.watcher.instance {
+		return
+	}
+
+	var err error
+	switch update.Op {
+	case updateAdd:
+		_, err = t.Enforcer.SelfAddPoliciesEx(update.Sec, update.Ptype, update.Rules)
+	case updateRemove:
+		_, err = t.Enforcer.SelfRemovePolicies(update.Sec, update.Ptype, update.Rules)
+	case updateRemoveFiltered:
+		_, err = t.Enforcer.SelfRemoveFilteredPolicy(update.Sec, update.Ptype, update.FieldIndex, update.FieldValues...)
+	default:
+		err = t.reload()
+	}
+	if err != nil {
+		slog.Error("Policy watcher: failed to apply update", "tenant", t.Name, "op", update.Op, "from", update.Instance, "error", err)
+		return
+	}
+	slog.Info("Policy watcher: applied update", "tenant", t.Name, "op", update.Op, "from", update.Instance)
+}
+
+// reload はポリシーをストレージから読み込み直す
+func (t *Tenant) reload() error {
+	if err := t.Enforcer.LoadPolicy(); err != nil {
+		return err
+	}
+	t.stats.lastReload.Store(time.Now().UnixNano())
+	return nil
+}
+
+// テナントのポリシーをストレージ（PostgreSQL / policy.csv）から読み込み直すハンドラ。
+// DB を直接変更した場合などに使う。PostgreSQL の場合は他のインスタンスにも再読み込みを通知する
+func reloadHandler(w http.ResponseWriter, r *http.Request) {
+	t := tenantFrom(r)
+	if err := t.reload(); err != nil {
+		http.Error(w, "Failed to reload policies: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	notified := false
+	if t.watcher != nil {
+		if err := t.watcher.Update(); err != nil {
+			slog.WarnContext(r.Context(), "Reload: failed to notify other instances", "error", err)
+		} else {
+			notified = true
+		}
+	}
+	slog.InfoContext(r.Context(), "Policies reloaded", "notified", notified)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"reloaded": true,
+		"policies": len(t.Enforcer.GetPolicy()),
+		"groups":   len(t.Enforcer.GetGroupingPolicy()),
+		"notified": notified,
+	})
+}
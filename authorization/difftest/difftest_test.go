@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestEnginesAgree はプロセス内の Casbin / OPA と SpiceDB の代替実装が、初期データの全組み合わせで同じ判定をすることを確認する
+func TestEnginesAgree(t *testing.T) {
+	const root = "../.."
+
+	s, err := loadSeed(root)
+	if err != nil {
+		t.Fatalf("初期データを読み込めません: %v", err)
+	}
+	servers, err := startStandIns(root)
+	if err != nil {
+		t.Fatalf("プロセス内の認可エンジンを起動できません: %v", err)
+	}
+	defer closeStandIns(servers)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	engines := standInEngines(servers)
+	decisions, err := compare(ctx, engines, s)
+	if err != nil {
+		t.Fatalf("認可チェックに失敗しました: %v", err)
+	}
+	if len(decisions) == 0 {
+		t.Fatal("比較する組み合わせがありません")
+	}
+
+	disagreements := 0
+	for _, d := range decisions {
+		if !d.Agree {
+			disagreements++
+			t.Errorf("%s %s:%s %s: %v", d.Subject, d.ResourceType, d.ResourceID, d.Permission, d.Results)
+		}
+	}
+	if disagreements > 0 {
+		t.Fatalf("%d/%d checks disagree", disagreements, len(decisions))
+	}
+}
@@ -0,0 +1,225 @@
+// difftest は初期データのユーザー × リソース × 権限をすべての認可エンジンに問い合わせ、
+// 判定が食い違う組み合わせを一覧にする。
+// デフォルトではリポジトリ内のポリシーファイルを読み込んだプロセス内のエンジン（Casbin / OPA）と
+// SpiceDB の代替実装を使い、-remote を指定した場合は起動中の認可サーバに問い合わせる
+package main
+
+import (
+	"authz"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var permissions = []string{authz.PermissionRead, authz.PermissionWrite, authz.PermissionDelete, authz.PermissionManageMembers}
+
+// 起動中の認可サーバのURLと SpiceDB の認証キー（-remote 用。docker compose の公開ポートがデフォルト）。
+// Casbin / OPA の認証キーは既定値を持たないため、-remote の場合は CASBIN_AUTH_KEY / OPA_AUTH_KEY の指定が必要
+var (
+	casbinServiceURL  = envOr("CASBIN_SERVICE_URL", "http://localhost:8080")
+	opaServiceURL     = envOr("OPA_SERVICE_URL", "http://localhost:8081")
+	spiceDBServiceURL = envOr("SPICEDB_SERVICE_URL", "http://localhost:8082")
+	spiceDBAuthKey    = envOr("SPICEDB_AUTH_KEY", "spicedb-secret-key")
+)
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// decision は1つの組み合わせに対する各エンジンの判定
+type decision struct {
+	Subject      string          `json:"subject"`
+	ResourceType string          `json:"resource_type"`
+	ResourceID   string          `json:"resource_id"`
+	Permission   string          `json:"permission"`
+	Results      map[string]bool `json:"results"`
+	Agree        bool            `json:"agree"`
+}
+
+func main() {
+	root := flag.String("root", "../..", "リポジトリのルート（初期データとポリシーファイルの場所）")
+	remote := flag.Bool("remote", false, "起動中の認可サーバに問い合わせる")
+	all := flag.Bool("all", false, "一致している組み合わせも出力する")
+	jsonOutput := flag.Bool("json", false, "結果を JSON で出力する")
+	timeout := flag.Duration("timeout", time.Minute, "全体のタイムアウト")
+	flag.Parse()
+
+	code, err := run(*root, *remote, *all, *jsonOutput, *timeout)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// 食い違いがある場合は終了コード 2
+	os.Exit(code)
+}
+
+func run(root string, remote, all, jsonOutput bool, timeout time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	s, err := loadSeed(root)
+	if err != nil {
+		return 1, fmt.Errorf("初期データを読み込めません: %w", err)
+	}
+
+	var engines []authz.Authorizer
+	if remote {
+		casbinAuthKey, opaAuthKey := os.Getenv("CASBIN_AUTH_KEY"), os.Getenv("OPA_AUTH_KEY")
+		if casbinAuthKey == "" || opaAuthKey == "" {
+			return 1, fmt.Errorf("-remote には CASBIN_AUTH_KEY と OPA_AUTH_KEY の指定が必要です")
+		}
+		engines = []authz.Authorizer{
+			authz.NewCasbinAuthorizer(casbinServiceURL, casbinAuthKey),
+			authz.NewOPAAuthorizer(opaServiceURL, opaAuthKey),
+			authz.NewSpiceDBAuthorizer(spiceDBServiceURL, spiceDBAuthKey),
+		}
+	} else {
+		servers, err := startStandIns(root)
+		if err != nil {
+			return 1, fmt.Errorf("プロセス内の認可エンジンを起動できません: %w", err)
+		}
+		defer closeStandIns(servers)
+		engines = standInEngines(servers)
+	}
+
+	decisions, err := compare(ctx, engines, s)
+	if err != nil {
+		return 1, fmt.Errorf("認可チェックに失敗しました: %w", err)
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(decisions)
+	} else {
+		printMatrix(engines, decisions, all)
+	}
+
+	for _, d := range decisions {
+		if !d.Agree {
+			return 2, nil
+		}
+	}
+	return 0, nil
+}
+
+// startStandIns は Casbin / OPA / SpiceDB の順にプロセス内のサーバを起動する
+func startStandIns(root string) ([]*httptest.Server, error) {
+	var servers []*httptest.Server
+	for _, start := range []func(string) (*httptest.Server, error){newCasbinStandIn, newOPAStandIn, newSpiceDBStandIn} {
+		srv, err := start(root)
+		if err != nil {
+			closeStandIns(servers)
+			return nil, err
+		}
+		servers = append(servers, srv)
+	}
+	return servers, nil
+}
+
+func closeStandIns(servers []*httptest.Server) {
+	for _, srv := range servers {
+		srv.Close()
+	}
+}
+
+// standInEngines は startStandIns で起動したサーバに問い合わせるクライアント
+func standInEngines(servers []*httptest.Server) []authz.Authorizer {
+	return []authz.Authorizer{
+		authz.NewCasbinAuthorizer(servers[0].URL, ""),
+		authz.NewOPAAuthorizer(servers[1].URL, ""),
+		authz.NewSpiceDBAuthorizer(servers[2].URL, ""),
+	}
+}
+
+// compare は全組み合わせを各エンジンに一括で問い合わせる
+func compare(ctx context.Context, engines []authz.Authorizer, s seed) ([]decision, error) {
+	var reqs []authz.Request
+	for _, user := range s.Users {
+		for _, resource := range []struct {
+			typ string
+			ids []string
+		}{{authz.ResourceSystem, s.Systems}, {authz.ResourceAWS, s.AWS}} {
+			for _, id := range resource.ids {
+				for _, permission := range permissions {
+					reqs = append(reqs, authz.Request{Subject: user, ResourceType: resource.typ, ResourceID: id, Permission: permission})
+				}
+			}
+		}
+	}
+
+	decisions := make([]decision, len(reqs))
+	for i, req := range reqs {
+		decisions[i] = decision{
+			Subject:      req.Subject,
+			ResourceType: req.ResourceType,
+			ResourceID:   req.ResourceID,
+			Permission:   req.Permission,
+			Results:      map[string]bool{},
+			Agree:        true,
+		}
+	}
+
+	for _, engine := range engines {
+		allowed, err := engine.BulkCheck(ctx, reqs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", engine.Name(), err)
+		}
+		for i := range decisions {
+			decisions[i].Results[engine.Name()] = allowed[i]
+		}
+	}
+
+	for i := range decisions {
+		first := decisions[i].Results[engines[0].Name()]
+		for _, engine := range engines[1:] {
+			if decisions[i].Results[engine.Name()] != first {
+				decisions[i].Agree = false
+			}
+		}
+	}
+	return decisions, nil
+}
+
+// printMatrix は判定をエンジンごとの列で出力する。食い違う行には ! を付ける
+func printMatrix(engines []authz.Authorizer, decisions []decision, all bool) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := []string{"", "SUBJECT", "RESOURCE", "PERMISSION"}
+	for _, engine := range engines {
+		header = append(header, strings.ToUpper(engine.Name()))
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+
+	disagreements := 0
+	for _, d := range decisions {
+		mark := ""
+		if !d.Agree {
+			mark = "!"
+			disagreements++
+		} else if !all {
+			continue
+		}
+
+		row := []string{mark, d.Subject, d.ResourceType + ":" + d.ResourceID, d.Permission}
+		for _, engine := range engines {
+			if d.Results[engine.Name()] {
+				row = append(row, "allow")
+			} else {
+				row = append(row, "deny")
+			}
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+
+	fmt.Printf("\n%d checks, %d disagreements\n", len(decisions), disagreements)
+}
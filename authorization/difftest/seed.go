@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// seed は初期データ（query/*/init.sql）に含まれるユーザーとリソース
+type seed struct {
+	Users   []string
+	Systems []string
+	AWS     []string
+}
+
+// INSERT INTO <table> (id, ...) VALUES ('<id>', ... の id を取り出す
+func insertIDPattern(table string) *regexp.Regexp {
+	return regexp.MustCompile(`(?m)^INSERT INTO ` + table + ` \(id,[^)]*\) VALUES \('([^']+)'`)
+}
+
+// loadSeed は root（リポジトリのルート）の init.sql からユーザーとリソースを読み込む
+func loadSeed(root string) (seed, error) {
+	var s seed
+	var err error
+	if s.Users, err = readInsertIDs(filepath.Join(root, "query/user/init.sql"), "user_info"); err != nil {
+		return s, err
+	}
+	if s.Systems, err = readInsertIDs(filepath.Join(root, "query/system/init.sql"), "system"); err != nil {
+		return s, err
+	}
+	if s.AWS, err = readInsertIDs(filepath.Join(root, "query/aws/init.sql"), "aws_account"); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+func readInsertIDs(path, table string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seed file: %w", err)
+	}
+
+	var ids []string
+	for _, m := range insertIDPattern(table).FindAllStringSubmatch(string(data), -1) {
+		ids = append(ids, m[1])
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no %s rows found in %s", table, path)
+	}
+	return ids, nil
+}
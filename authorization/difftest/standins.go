@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/util"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/storage/inmem"
+	"gopkg.in/yaml.v2"
+)
+
+// 各認可サーバと同じ形式のリクエスト/レスポンス（/authorize/batch）
+type batchRequest[T any] struct {
+	Requests []T `json:"requests"`
+}
+
+type batchResult struct {
+	Allowed bool `json:"allowed"`
+}
+
+type batchResponse struct {
+	Results []batchResult `json:"results"`
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// newCasbinStandIn は authorization/casbin の model.conf / policy.csv を読み込んだ
+// Casbin サーバ互換の /authorize/batch を提供する
+func newCasbinStandIn(root string) (*httptest.Server, error) {
+	dir := filepath.Join(root, "authorization/casbin")
+	enforcer, err := casbin.NewEnforcer(filepath.Join(dir, "model.conf"), filepath.Join(dir, "policy.csv"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load casbin policy: %w", err)
+	}
+	// Casbin サーバと同じく、g のドメインのパターン（system:*）によるロールの継承を有効にする
+	enforcer.AddNamedDomainMatchingFunc("g", "keyMatch", util.KeyMatch)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /authorize/batch", func(w http.ResponseWriter, r *http.Request) {
+		var req batchRequest[struct {
+			Subject string `json:"subject"`
+			Domain  string `json:"domain"`
+			Object  string `json:"object"`
+			Action  string `json:"action"`
+		}]
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		// 属性の条件（cond）は比較の対象外のため、属性は空で判定する
+		rvals := make([][]interface{}, len(req.Requests))
+		for i, q := range req.Requests {
+			rvals[i] = []interface{}{q.Subject, q.Domain, q.Object, q.Action, map[string]interface{}{}}
+		}
+		allowed, err := enforcer.BatchEnforce(rvals)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		resp := batchResponse{Results: make([]batchResult, len(allowed))}
+		for i, ok := range allowed {
+			resp.Results[i].Allowed = ok
+		}
+		writeJSON(w, resp)
+	})
+	return httptest.NewServer(mux), nil
+}
+
+// newOPAStandIn は authorization/opa の policy.rego と data.json を読み込んだ
+// OPA サーバ互換の /authorize/batch を提供する
+func newOPAStandIn(root string) (*httptest.Server, error) {
+	dir := filepath.Join(root, "authorization/opa")
+	policy, err := os.ReadFile(filepath.Join(dir, "policy.rego"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OPA policy: %w", err)
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, "data.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OPA data: %w", err)
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse OPA data: %w", err)
+	}
+
+	query, err := rego.New(
+		rego.Query("data.authz.allow"),
+		rego.Module("policy.rego", string(policy)),
+		rego.Store(inmem.NewFromObject(data)),
+	).PrepareForEval(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare OPA query: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /authorize/batch", func(w http.ResponseWriter, r *http.Request) {
+		var req batchRequest[struct {
+			Subject    string `json:"subject"`
+			Resource   string `json:"resource"`
+			Permission string `json:"permission"`
+		}]
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		resp := batchResponse{Results: make([]batchResult, len(req.Requests))}
+		for i, q := range req.Requests {
+			input := map[string]interface{}{
+				"subject":    q.Subject,
+				"resource":   q.Resource,
+				"permission": q.Permission,
+			}
+			results, err := query.Eval(r.Context(), rego.EvalInput(input))
+			if err != nil {
+				http.Error(w, "Policy evaluation failed: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if len(results) > 0 && len(results[0].Expressions) > 0 {
+				resp.Results[i].Allowed, _ = results[0].Expressions[0].Value.(bool)
+			}
+		}
+		writeJSON(w, resp)
+	})
+	return httptest.NewServer(mux), nil
+}
+
+// spiceDBModel は relationships.yaml のスキーマとリレーションを保持する SpiceDB の代替実装。
+// 権限式は直接のリレーション（および他の権限）の和（+）のみに対応する
+type spiceDBModel struct {
+	// 定義 → 権限 → 和を構成するリレーション/権限
+	permissions map[string]map[string][]string
+	// "type:id#relation@user:subject"
+	tuples map[string]bool
+}
+
+var (
+	zedDefinitionPattern = regexp.MustCompile(`definition\s+(\w+)\s*\{([^}]*)\}`)
+	zedPermissionPattern = regexp.MustCompile(`(?m)^\s*permission\s+(\w+)\s*=\s*(.+?)\s*$`)
+	zedTuplePattern      = regexp.MustCompile(`^(\w+):([^#]+)#(\w+)@(\w+):(\S+)$`)
+	zedIdentifierPattern = regexp.MustCompile(`^\w+$`)
+)
+
+func loadSpiceDBModel(root string) (*spiceDBModel, error) {
+	data, err := os.ReadFile(filepath.Join(root, "authorization/spicedb/relationships.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SpiceDB relationships: %w", err)
+	}
+	var file struct {
+		Schema        string `yaml:"schema"`
+		Relationships string `yaml:"relationships"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse SpiceDB relationships: %w", err)
+	}
+
+	m := &spiceDBModel{permissions: map[string]map[string][]string{}, tuples: map[string]bool{}}
+	for _, def := range zedDefinitionPattern.FindAllStringSubmatch(file.Schema, -1) {
+		perms := map[string][]string{}
+		for _, p := range zedPermissionPattern.FindAllStringSubmatch(def[2], -1) {
+			var terms []string
+			for _, term := range strings.Split(p[2], "+") {
+				term = strings.TrimSpace(term)
+				if !zedIdentifierPattern.MatchString(term) {
+					return nil, fmt.Errorf("unsupported permission expression in %s.%s: %s", def[1], p[1], p[2])
+				}
+				terms = append(terms, term)
+			}
+			perms[p[1]] = terms
+		}
+		m.permissions[def[1]] = perms
+	}
+
+	for _, line := range strings.Split(file.Relationships, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		if !zedTuplePattern.MatchString(line) {
+			return nil, fmt.Errorf("unsupported relationship: %s", line)
+		}
+		m.tuples[line] = true
+	}
+	return m, nil
+}
+
+// check は subject が resourceType:resourceID の permission（またはリレーション）を持つかを返す
+func (m *spiceDBModel) check(resourceType, resourceID, permission, subject string) bool {
+	if terms, ok := m.permissions[resourceType][permission]; ok {
+		for _, term := range terms {
+			if m.check(resourceType, resourceID, term, subject) {
+				return true
+			}
+		}
+		return false
+	}
+	return m.tuples[fmt.Sprintf("%s:%s#%s@user:%s", resourceType, resourceID, permission, subject)]
+}
+
+// newSpiceDBStandIn は SpiceDB HTTP API 互換の /v1/permissions/checkbulk を提供する
+func newSpiceDBStandIn(root string) (*httptest.Server, error) {
+	model, err := loadSpiceDBModel(root)
+	if err != nil {
+		return nil, err
+	}
+
+	type objectRef struct {
+		ObjectType string `json:"objectType"`
+		ObjectId   string `json:"objectId"`
+	}
+	type pairItem struct {
+		Permissionship string `json:"permissionship"`
+	}
+	type pairError struct {
+		Message string `json:"message"`
+	}
+	type pair struct {
+		Item  *pairItem  `json:"item,omitempty"`
+		Error *pairError `json:"error,omitempty"`
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/permissions/checkbulk", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Items []struct {
+				Resource   objectRef `json:"resource"`
+				Permission string    `json:"permission"`
+				Subject    struct {
+					Object objectRef `json:"object"`
+				} `json:"subject"`
+			} `json:"items"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		pairs := make([]pair, len(req.Items))
+		for i, item := range req.Items {
+			if _, ok := model.permissions[item.Resource.ObjectType][item.Permission]; !ok {
+				pairs[i].Error = &pairError{Message: fmt.Sprintf("permission %s not found on %s", item.Permission, item.Resource.ObjectType)}
+				continue
+			}
+			permissionship := "PERMISSIONSHIP_NO_PERMISSION"
+			if item.Subject.Object.ObjectType == "user" && model.check(item.Resource.ObjectType, item.Resource.ObjectId, item.Permission, item.Subject.Object.ObjectId) {
+				permissionship = "PERMISSIONSHIP_HAS_PERMISSION"
+			}
+			pairs[i].Item = &pairItem{Permissionship: permissionship}
+		}
+		writeJSON(w, map[string]interface{}{"pairs": pairs})
+	})
+	return httptest.NewServer(mux), nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ロールはドメイン（リソースは "<種別>:<ID>"、それ以外は global）ごとに割り当てる。
+// global に割り当てたロールはすべてのドメインで有効。
+// g のドメインは keyMatch で比較する（Casbin サーバで AddNamedDomainMatchingFunc を設定する）ため、
+// ドメインのパターン（system:*）に対する g 行はそのパターンに一致するすべてのドメインで有効。
+// eft が deny の p 行に一致した場合は allow の行があっても拒否する。
+// cond はリクエストの属性（r.attrs）に対する条件で、Casbin サーバが登録する関数（timeBetween / ipInRange など）を使える
+const casbinModel = `[request_definition]
+r = sub, dom, obj, act, attrs
+
+[policy_definition]
+p = sub, dom, obj, act, eft, cond
+
+[role_definition]
+g = _, _, _
+
+[policy_effect]
+e = some(where (p.eft == allow)) && !some(where (p.eft == deny))
+
+[matchers]
+m = (g(r.sub, p.sub, r.dom) || g(r.sub, p.sub, "global")) && keyMatch(r.dom, p.dom) && keyMatch(r.obj, p.obj) && (p.act == "*" || r.act == p.act) && eval(p.cond)
+`
+
+// グローバル管理者に割り当てる Casbin のロールとドメイン
+const (
+	casbinAdminRole    = "admin"
+	casbinGlobalDomain = "global"
+)
+
+// p 行の eft と cond の既定値
+const (
+	casbinDefaultEffect    = "allow"
+	casbinDefaultCondition = "true"
+)
+
+func generateCasbinModel(m *Model) []byte {
+	return []byte("# " + generatedNotice + "\n\n" + casbinModel)
+}
+
+// casbinDomain はリソースのドメイン（例: system:system1）。authz.casbinDomain と同じ形式
+func casbinDomain(resourceType, resourceID string) string {
+	return resourceType + ":" + resourceID
+}
+
+// generateCasbinPolicy は policy.csv を生成する。
+// ロールの権限はリソース種別ごとに1回だけ定義し、g 行でドメインごとに割り当てる。
+// ロールの継承（owner → manager → staff）はドメインのパターン（system:*）の g 行で表し、
+// p 行には継承元にない権限だけを出力する
+func generateCasbinPolicy(m *Model) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", generatedNotice)
+
+	b.WriteString("# グローバル管理者\n")
+	fmt.Fprintf(&b, "p, %s\n", casbinPolicyRow(casbinAdminRole, "*", "*", "*"))
+
+	actions := map[string]string{}
+	for _, p := range m.Permissions {
+		actions[p.Name] = p.CasbinAction
+	}
+
+	for _, rt := range m.ResourceTypes {
+		fmt.Fprintf(&b, "\n# %sのロール（ドメイン %s）\n", rt.Description, casbinDomain(rt.Name, "<ID>"))
+		for _, role := range rt.Roles {
+			inherited := map[string]bool{}
+			for _, parent := range role.Inherits {
+				for _, p := range m.effectivePermissions(rt, parent) {
+					inherited[p] = true
+				}
+			}
+			for _, permission := range role.Permissions {
+				if !inherited[permission] {
+					fmt.Fprintf(&b, "p, %s\n", casbinPolicyRow(role.Name, casbinDomain(rt.Name, "*"), "/"+rt.Name+"/*", actions[permission]))
+				}
+			}
+		}
+	}
+
+	if len(m.Casbin.ExtraPolicies) > 0 {
+		b.WriteString("\n# その他\n")
+		for _, policy := range m.Casbin.ExtraPolicies {
+			fmt.Fprintf(&b, "p, %s\n", casbinPolicyRow(policy...))
+		}
+	}
+
+	b.WriteString("\n# ロールの継承（ロール, 継承元のロール, ドメインのパターン）。admin は各リソース種別の最上位のロールを継承する\n")
+	for _, rt := range m.ResourceTypes {
+		for _, role := range rt.Roles {
+			for _, parent := range role.Inherits {
+				fmt.Fprintf(&b, "g, %s, %s, %s\n", role.Name, parent, casbinDomain(rt.Name, "*"))
+			}
+		}
+		for _, role := range rt.topRoles() {
+			fmt.Fprintf(&b, "g, %s, %s, %s\n", casbinAdminRole, role, casbinDomain(rt.Name, "*"))
+		}
+	}
+
+	b.WriteString("\n# ロール割り当て（ユーザー, ロール, ドメイン）\n")
+	for _, admin := range m.Admins {
+		fmt.Fprintf(&b, "g, %s, %s, %s\n", admin, casbinAdminRole, casbinGlobalDomain)
+	}
+	for _, rt := range m.ResourceTypes {
+		for _, a := range rt.assignments() {
+			fmt.Fprintf(&b, "g, %s, %s, %s\n", a.Subject, a.Role, casbinDomain(rt.Name, a.ResourceID))
+		}
+	}
+	return []byte(b.String())
+}
+
+// casbinPolicyRow は p 行の値を eft / cond の既定値で補い、CSV の1行にする。
+// cond はカンマや引用符を含むため、必要な場合は引用符で囲む
+func casbinPolicyRow(policy ...string) string {
+	row := append([]string{}, policy...)
+	if len(row) == 4 {
+		row = append(row, casbinDefaultEffect)
+	}
+	if len(row) == 5 {
+		row = append(row, casbinDefaultCondition)
+	}
+	for i, field := range row {
+		if strings.ContainsAny(field, ",\"") {
+			row[i] = `"` + strings.ReplaceAll(field, `"`, `""`) + `"`
+		}
+	}
+	return strings.Join(row, ", ")
+}
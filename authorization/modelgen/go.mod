@@ -0,0 +1,5 @@
+module authorization-modelgen
+
+go 1.23.1
+
+require gopkg.in/yaml.v2 v2.4.0
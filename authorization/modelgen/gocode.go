@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"strings"
+)
+
+// generateGoRolePermissions は authz パッケージの RolePermissions（メンバー変更時のロールの検証などで使う）を生成する
+func generateGoRolePermissions(m *Model) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString("// Code generated by authorization/modelgen from authorization/model.yaml. DO NOT EDIT.\n\n")
+	b.WriteString("package authz\n\n")
+	b.WriteString("// RolePermissions はリソース種別ごとのロールと（継承を含めた）権限の対応。\n")
+	b.WriteString("// メンバー変更やエンジンから読み出したロール割り当ての検証に使う\n")
+	b.WriteString("var RolePermissions = map[string]map[string][]string{\n")
+	for _, rt := range m.ResourceTypes {
+		fmt.Fprintf(&b, "%q: {\n", rt.Name)
+		for _, role := range rt.Roles {
+			permissions := m.effectivePermissions(rt, role.Name)
+			quoted := make([]string, len(permissions))
+			for i, p := range permissions {
+				quoted[i] = fmt.Sprintf("%q", p)
+			}
+			fmt.Fprintf(&b, "%q: {%s},\n", role.Name, strings.Join(quoted, ", "))
+		}
+		b.WriteString("},\n")
+	}
+	b.WriteString("}\n")
+
+	src, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated Go code: %w", err)
+	}
+	return src, nil
+}
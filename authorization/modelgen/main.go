@@ -0,0 +1,86 @@
+// modelgen は authorization/model.yaml（共通の認可モデル）から各認可エンジンの設定ファイルを生成する。
+//   - Casbin: model.conf / policy.csv
+//   - OPA: data.json（policy.rego が参照するデータ）
+//   - SpiceDB: schema.zed / relationships.yaml
+//   - apps/backend/authz: RolePermissions
+//
+// -check を指定した場合はファイルを書き換えず、生成結果と異なるファイルがあれば終了コード 1 で終わる
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// generatedNotice は生成したファイルの先頭に入れる注意書き
+const generatedNotice = "このファイルは authorization/model.yaml から authorization/modelgen で生成しています。直接編集しないでください"
+
+// artifact は生成するファイル（path はリポジトリのルートからの相対パス）
+type artifact struct {
+	path     string
+	generate func(*Model) ([]byte, error)
+}
+
+func static(f func(*Model) []byte) func(*Model) ([]byte, error) {
+	return func(m *Model) ([]byte, error) { return f(m), nil }
+}
+
+var artifacts = []artifact{
+	{"authorization/casbin/model.conf", static(generateCasbinModel)},
+	{"authorization/casbin/policy.csv", static(generateCasbinPolicy)},
+	{"authorization/opa/data.json", generateOPAData},
+	{"authorization/spicedb/schema.zed", static(generateSpiceDBSchema)},
+	{"authorization/spicedb/relationships.yaml", static(generateSpiceDBRelationships)},
+	{"apps/backend/authz/role_permissions_gen.go", generateGoRolePermissions},
+}
+
+func main() {
+	root := flag.String("root", "../..", "リポジトリのルート")
+	modelPath := flag.String("model", "", "認可モデルのファイル（デフォルトは <root>/authorization/model.yaml）")
+	check := flag.Bool("check", false, "ファイルを書き換えず、生成結果と異なる場合に失敗する")
+	flag.Parse()
+
+	if *modelPath == "" {
+		*modelPath = filepath.Join(*root, "authorization/model.yaml")
+	}
+	m, err := loadModel(*modelPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	stale := 0
+	for _, a := range artifacts {
+		want, err := a.generate(m)
+		if err != nil {
+			log.Fatalf("%s: %v", a.path, err)
+		}
+		path := filepath.Join(*root, a.path)
+
+		got, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			log.Fatalf("%s: %v", a.path, err)
+		}
+		if bytes.Equal(got, want) {
+			continue
+		}
+
+		if *check {
+			fmt.Printf("stale: %s\n", a.path)
+			stale++
+			continue
+		}
+		if err := os.WriteFile(path, want, 0o644); err != nil {
+			log.Fatalf("%s: %v", a.path, err)
+		}
+		fmt.Printf("generated: %s\n", a.path)
+	}
+
+	if stale > 0 {
+		fmt.Printf("\n%d file(s) are out of date with %s. Run modelgen to regenerate them.\n", stale, *modelPath)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Model は authorization/model.yaml の内容
+type Model struct {
+	Permissions   []Permission   `yaml:"permissions"`
+	Admins        []string       `yaml:"admins"`
+	ResourceTypes []ResourceType `yaml:"resource_types"`
+	Casbin        struct {
+		ExtraPolicies [][]string `yaml:"extra_policies"`
+	} `yaml:"casbin"`
+}
+
+type Permission struct {
+	Name         string `yaml:"name"`
+	CasbinAction string `yaml:"casbin_action"`
+}
+
+type ResourceType struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Roles       []Role `yaml:"roles"`
+	// リソースID → ユーザー → ロール
+	Assignments map[string]map[string]string `yaml:"assignments"`
+}
+
+// Role の Permissions は継承元（Inherits）のロールの権限に追加する権限
+type Role struct {
+	Name        string   `yaml:"name"`
+	Inherits    []string `yaml:"inherits"`
+	Permissions []string `yaml:"permissions"`
+}
+
+// Assignment は1件のロール割り当て
+type Assignment struct {
+	ResourceID string
+	Subject    string
+	Role       string
+}
+
+func loadModel(path string) (*Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model: %w", err)
+	}
+
+	var m Model
+	if err := yaml.UnmarshalStrict(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse model: %w", err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("invalid model: %w", err)
+	}
+	return &m, nil
+}
+
+// validate は名前の重複や未定義の権限・ロールの参照がないかを確認する
+func (m *Model) validate() error {
+	if len(m.Permissions) == 0 {
+		return fmt.Errorf("no permissions defined")
+	}
+	permissions := map[string]bool{}
+	for _, p := range m.Permissions {
+		if p.Name == "" || p.CasbinAction == "" {
+			return fmt.Errorf("permission requires name and casbin_action: %+v", p)
+		}
+		if permissions[p.Name] {
+			return fmt.Errorf("duplicate permission: %s", p.Name)
+		}
+		permissions[p.Name] = true
+	}
+
+	for _, policy := range m.Casbin.ExtraPolicies {
+		if len(policy) < 4 || len(policy) > 6 {
+			return fmt.Errorf("casbin policy must have 4 to 6 elements (sub, dom, obj, act[, eft[, cond]]): %v", policy)
+		}
+		if len(policy) >= 5 && policy[4] != "allow" && policy[4] != "deny" {
+			return fmt.Errorf("casbin policy eft must be allow or deny: %v", policy)
+		}
+	}
+
+	resourceTypes := map[string]bool{}
+	for _, rt := range m.ResourceTypes {
+		if rt.Name == "" {
+			return fmt.Errorf("resource type requires name")
+		}
+		if resourceTypes[rt.Name] {
+			return fmt.Errorf("duplicate resource type: %s", rt.Name)
+		}
+		resourceTypes[rt.Name] = true
+
+		roles := map[string]bool{}
+		for _, role := range rt.Roles {
+			if roles[role.Name] {
+				return fmt.Errorf("duplicate role in %s: %s", rt.Name, role.Name)
+			}
+			roles[role.Name] = true
+			for _, p := range role.Permissions {
+				if !permissions[p] {
+					return fmt.Errorf("unknown permission in %s.%s: %s", rt.Name, role.Name, p)
+				}
+			}
+		}
+		for _, role := range rt.Roles {
+			for _, parent := range role.Inherits {
+				if !roles[parent] {
+					return fmt.Errorf("unknown role inherited by %s.%s: %s", rt.Name, role.Name, parent)
+				}
+			}
+			if rt.inheritsFrom(role.Name, role.Name, map[string]bool{}) {
+				return fmt.Errorf("role inheritance cycle in %s: %s", rt.Name, role.Name)
+			}
+		}
+		// SpiceDB の permission は空にできないため、すべての権限にロールが必要
+		for _, p := range m.Permissions {
+			if len(m.rolesWith(rt, p.Name)) == 0 {
+				return fmt.Errorf("no role in %s has permission %s", rt.Name, p.Name)
+			}
+		}
+
+		for _, a := range rt.assignments() {
+			if !roles[a.Role] {
+				return fmt.Errorf("unknown role in %s:%s assignment for %s: %s", rt.Name, a.ResourceID, a.Subject, a.Role)
+			}
+		}
+	}
+	return nil
+}
+
+// role は名前が name のロールを返す
+func (rt ResourceType) role(name string) Role {
+	return rt.Roles[rt.roleIndex(name)]
+}
+
+// inheritsFrom は name が（推移的に）target を継承しているかを返す
+func (rt ResourceType) inheritsFrom(name, target string, seen map[string]bool) bool {
+	if seen[name] {
+		return false
+	}
+	seen[name] = true
+	for _, parent := range rt.role(name).Inherits {
+		if parent == target || rt.inheritsFrom(parent, target, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// effectivePermissions は継承した権限を含むロールの権限を、model の permissions の定義順で返す
+func (m *Model) effectivePermissions(rt ResourceType, name string) []string {
+	has := map[string]bool{}
+	var collect func(name string)
+	collect = func(name string) {
+		role := rt.role(name)
+		for _, p := range role.Permissions {
+			has[p] = true
+		}
+		for _, parent := range role.Inherits {
+			collect(parent)
+		}
+	}
+	collect(name)
+
+	permissions := []string{}
+	for _, p := range m.Permissions {
+		if has[p.Name] {
+			permissions = append(permissions, p.Name)
+		}
+	}
+	return permissions
+}
+
+// topRoles は他のロールから継承されていないロール（階層の最上位）を定義順に返す
+func (rt ResourceType) topRoles() []string {
+	inherited := map[string]bool{}
+	for _, role := range rt.Roles {
+		for _, parent := range role.Inherits {
+			inherited[parent] = true
+		}
+	}
+	var roles []string
+	for _, role := range rt.Roles {
+		if !inherited[role.Name] {
+			roles = append(roles, role.Name)
+		}
+	}
+	return roles
+}
+
+// rolesWith は（継承を含めて）permission を持つロールを定義順に返す
+func (m *Model) rolesWith(rt ResourceType, permission string) []string {
+	var roles []string
+	for _, role := range rt.Roles {
+		for _, p := range m.effectivePermissions(rt, role.Name) {
+			if p == permission {
+				roles = append(roles, role.Name)
+				break
+			}
+		}
+	}
+	return roles
+}
+
+// roleIndex はロールの定義順（出力の並び順に使う）
+func (rt ResourceType) roleIndex(name string) int {
+	for i, role := range rt.Roles {
+		if role.Name == name {
+			return i
+		}
+	}
+	return len(rt.Roles)
+}
+
+// assignments はロール割り当てをリソースID・ロールの定義順・ユーザーの順に並べて返す
+func (rt ResourceType) assignments() []Assignment {
+	var list []Assignment
+	for resourceID, subjects := range rt.Assignments {
+		for subject, role := range subjects {
+			list = append(list, Assignment{ResourceID: resourceID, Subject: subject, Role: role})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.ResourceID != b.ResourceID {
+			return a.ResourceID < b.ResourceID
+		}
+		if ai, bi := rt.roleIndex(a.Role), rt.roleIndex(b.Role); ai != bi {
+			return ai < bi
+		}
+		return a.Subject < b.Subject
+	})
+	return list
+}
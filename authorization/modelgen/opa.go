@@ -0,0 +1,49 @@
+package main
+
+import "encoding/json"
+
+// opaGlobalAdminRole は policy.rego がフルアクセスとみなすグローバルロール
+const opaGlobalAdminRole = "admin"
+
+// generateOPAData は policy.rego が参照するデータドキュメント（data.json）を生成する。
+//   - global_roles: ユーザー → グローバルロール
+//   - memberships: リソース種別 → ユーザー → リソースID → ロール
+//   - role_permissions: リソース種別 → ロール → 権限
+//
+// JSON には生成元を書けないため、注意書きは _comment に入れる
+func generateOPAData(m *Model) ([]byte, error) {
+	globalRoles := map[string]string{}
+	for _, admin := range m.Admins {
+		globalRoles[admin] = opaGlobalAdminRole
+	}
+
+	memberships := map[string]map[string]map[string]string{}
+	rolePermissions := map[string]map[string][]string{}
+	for _, rt := range m.ResourceTypes {
+		bySubject := map[string]map[string]string{}
+		for _, a := range rt.assignments() {
+			if bySubject[a.Subject] == nil {
+				bySubject[a.Subject] = map[string]string{}
+			}
+			bySubject[a.Subject][a.ResourceID] = a.Role
+		}
+		memberships[rt.Name] = bySubject
+
+		roles := map[string][]string{}
+		for _, role := range rt.Roles {
+			roles[role.Name] = m.effectivePermissions(rt, role.Name)
+		}
+		rolePermissions[rt.Name] = roles
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"_comment":         generatedNotice,
+		"global_roles":     globalRoles,
+		"memberships":      memberships,
+		"role_permissions": rolePermissions,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -26,15 +26,27 @@ type AuthResponse struct {
 	Debug   interface{} `json:"debug,omitempty"`
 }
 
+// 一括認可チェック用の構造体
+type BatchAuthRequest struct {
+	Requests []AuthRequest `json:"requests"`
+}
+
+type BatchAuthResponse struct {
+	Results []AuthResponse `json:"results"`
+}
+
+// 一括認可チェックで受け付ける最大件数
+const maxBatchSize = 1000
+
 type PolicyRequest struct {
 	Query string      `json:"query"`
 	Input interface{} `json:"input"`
 }
 
 type Config struct {
-	Users           map[string]User         `yaml:"users"`
-	Resources       Resources               `yaml:"resources"`
-	RolePermissions map[string][]string     `yaml:"role_permissions"`
+	Users           map[string]User     `yaml:"users"`
+	Resources       Resources           `yaml:"resources"`
+	RolePermissions map[string][]string `yaml:"role_permissions"`
 }
 
 type User struct {
@@ -69,7 +81,11 @@ var (
 func enableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// CORSヘッダーを設定
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		// 許可したオリジンのみ返す（認可サーバはサーバ間通信が基本のため "*" にしない）
+		if origin := r.Header.Get("Origin"); origin != "" && isAllowedOrigin(origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		w.Header().Set("Vary", "Origin")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
 		
@@ -86,11 +102,23 @@ func enableCORS(next http.Handler) http.Handler {
 func main() {
 	fmt.Println("Initializing OPA Authorization Server...")
 
+	// サービス間認証の設定
+	var err error
+	serviceAuth, err = loadServiceAuthConfig()
+	if err != nil {
+		log.Fatal("Failed to load service auth config: ", err)
+	}
+
 	// 設定ファイルの読み込み
 	if err := loadConfig(); err != nil {
 		log.Fatal("Failed to load config:", err)
 	}
 
+	// ポリシーが参照するデータストアの準備
+	if err := initializeStore(); err != nil {
+		log.Fatal("Failed to initialize store:", err)
+	}
+
 	// OPAポリシーの準備
 	if err := initializeOPA(); err != nil {
 		log.Fatal("Failed to initialize OPA:", err)
@@ -98,15 +126,22 @@ func main() {
 
 	router := mux.NewRouter()
 
-	// API endpoints
-	router.HandleFunc("/authorize", authorizeHandler).Methods("POST")
+	// API endpoints（判定系・変更系はサービス間認証が必要）
+	router.HandleFunc("/authorize", requireServiceAuth(authorizeHandler)).Methods("POST")
 	router.HandleFunc("/authorize", optionsHandler).Methods("OPTIONS")
-	router.HandleFunc("/evaluate", evaluateHandler).Methods("POST")
+	router.HandleFunc("/authorize/batch", requireServiceAuth(batchAuthorizeHandler)).Methods("POST")
+	router.HandleFunc("/authorize/batch", optionsHandler).Methods("OPTIONS")
+	router.HandleFunc("/evaluate", requireServiceAuth(evaluateHandler)).Methods("POST")
 	router.HandleFunc("/evaluate", optionsHandler).Methods("OPTIONS")
 	router.HandleFunc("/users", getUsersHandler).Methods("GET")
 	router.HandleFunc("/users", optionsHandler).Methods("OPTIONS")
 	router.HandleFunc("/resources", getResourcesHandler).Methods("GET")
 	router.HandleFunc("/resources", optionsHandler).Methods("OPTIONS")
+	router.HandleFunc("/memberships", getMembershipsHandler).Methods("GET")
+	router.HandleFunc("/memberships", optionsHandler).Methods("OPTIONS")
+	router.HandleFunc("/memberships/{type}/{resourceId}/{subject}", requireServiceAuth(putMembershipHandler)).Methods("PUT")
+	router.HandleFunc("/memberships/{type}/{resourceId}/{subject}", requireServiceAuth(deleteMembershipHandler)).Methods("DELETE")
+	router.HandleFunc("/memberships/{type}/{resourceId}/{subject}", optionsHandler).Methods("OPTIONS")
 	router.HandleFunc("/health", healthHandler).Methods("GET")
 	router.HandleFunc("/health", optionsHandler).Methods("OPTIONS")
 
@@ -120,7 +155,7 @@ func main() {
 
 	fmt.Printf("OPA Authorization Server starting on port %s\n", port)
 	fmt.Printf("Loaded %d users from config file\n", len(config.Users))
-	log.Fatal(http.ListenAndServe(":"+port, corsHandler))
+	log.Fatal(serviceAuth.listenAndServe(":"+port, corsHandler))
 }
 
 func loadConfig() error {
@@ -156,6 +191,7 @@ func initializeOPA() error {
 	query, err := rego.New(
 		rego.Query("data.authz.allow"),
 		rego.Module("policy.rego", string(policyData)),
+		rego.Store(store),
 	).PrepareForEval(context.Background())
 
 	if err != nil {
@@ -197,6 +233,7 @@ func authorizeHandler(w http.ResponseWriter, r *http.Request) {
 	reasonQuery, _ := rego.New(
 		rego.Query("data.authz.reason"),
 		rego.Module("policy.rego", getPolicyContent()),
+		rego.Store(store),
 	).PrepareForEval(context.Background())
 
 	reasonResults, _ := reasonQuery.Eval(context.Background(), rego.EvalInput(input))
@@ -216,6 +253,47 @@ func authorizeHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(authResponse)
 }
 
+// 複数の認可チェックを1リクエストで行うハンドラ。結果は requests と同じ順序で返す
+func batchAuthorizeHandler(w http.ResponseWriter, r *http.Request) {
+	var batchReq BatchAuthRequest
+	if err := json.NewDecoder(r.Body).Decode(&batchReq); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(batchReq.Requests) > maxBatchSize {
+		http.Error(w, fmt.Sprintf("Too many requests in batch (max %d)", maxBatchSize), http.StatusBadRequest)
+		return
+	}
+
+	// 準備済みのクエリを入力ごとに評価する（ポリシーの再コンパイルは行わない）
+	response := BatchAuthResponse{Results: make([]AuthResponse, len(batchReq.Requests))}
+	for i, authReq := range batchReq.Requests {
+		input := map[string]interface{}{
+			"subject":    authReq.Subject,
+			"resource":   authReq.Resource,
+			"permission": authReq.Permission,
+		}
+
+		results, err := regoQuery.Eval(r.Context(), rego.EvalInput(input))
+		if err != nil {
+			http.Error(w, "Policy evaluation failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		allowed := false
+		if len(results) > 0 && len(results[0].Expressions) > 0 {
+			if val, ok := results[0].Expressions[0].Value.(bool); ok {
+				allowed = val
+			}
+		}
+		response.Results[i] = AuthResponse{Allowed: allowed}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 func evaluateHandler(w http.ResponseWriter, r *http.Request) {
 	var policyReq PolicyRequest
 	if err := json.NewDecoder(r.Body).Decode(&policyReq); err != nil {
@@ -227,6 +305,7 @@ func evaluateHandler(w http.ResponseWriter, r *http.Request) {
 	query, err := rego.New(
 		rego.Query(policyReq.Query),
 		rego.Module("policy.rego", getPolicyContent()),
+		rego.Store(store),
 	).PrepareForEval(context.Background())
 
 	if err != nil {
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/gorilla/mux"
+	"github.com/open-policy-agent/opa/storage"
+	"github.com/open-policy-agent/opa/storage/inmem"
+)
+
+// リソース種別 → 割り当て可能なロール（data.json の role_permissions から読み込む）
+var membershipRoles = map[string]map[string]bool{}
+
+type MembershipRequest struct {
+	Role string `json:"role"`
+}
+
+// ポリシーが参照するデータ（data.global_roles / data.memberships / data.role_permissions）。
+// メンバー変更APIで data.memberships が更新される
+var store storage.Store
+
+// initializeStore は data.json（authorization/model.yaml から生成）を初期データとしてストアを作成する
+func initializeStore() error {
+	dataPath := "./data.json"
+	if _, err := os.Stat("/app/data.json"); err == nil {
+		dataPath = "/app/data.json" // Docker環境用
+	}
+
+	raw, err := os.ReadFile(dataPath)
+	if err != nil {
+		return fmt.Errorf("failed to read data file: %v", err)
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return fmt.Errorf("failed to parse data file: %v", err)
+	}
+
+	rolePermissions, ok := data["role_permissions"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("role_permissions not found in %s", dataPath)
+	}
+	memberships, ok := data["memberships"].(map[string]interface{})
+	if !ok {
+		memberships = map[string]interface{}{}
+		data["memberships"] = memberships
+	}
+	for resourceType, roles := range rolePermissions {
+		roles, ok := roles.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("invalid role_permissions for %s", resourceType)
+		}
+		membershipRoles[resourceType] = map[string]bool{}
+		for role := range roles {
+			membershipRoles[resourceType][role] = true
+		}
+		if _, ok := memberships[resourceType]; !ok {
+			memberships[resourceType] = map[string]interface{}{}
+		}
+	}
+
+	store = inmem.NewFromObject(data)
+	return nil
+}
+
+// setMembership は subject の resourceID に対するロールを設定する（既存のロールは置き換える）
+func setMembership(ctx context.Context, resourceType, resourceID, subject, role string) error {
+	txn, err := store.NewTransaction(ctx, storage.WriteParams)
+	if err != nil {
+		return err
+	}
+
+	subjectPath := storage.Path{"memberships", resourceType, subject}
+	if _, err := store.Read(ctx, txn, subjectPath); err != nil {
+		if !storage.IsNotFound(err) {
+			store.Abort(ctx, txn)
+			return err
+		}
+		if err := store.Write(ctx, txn, storage.AddOp, subjectPath, map[string]interface{}{}); err != nil {
+			store.Abort(ctx, txn)
+			return err
+		}
+	}
+
+	if err := store.Write(ctx, txn, storage.AddOp, append(subjectPath, resourceID), role); err != nil {
+		store.Abort(ctx, txn)
+		return err
+	}
+	return store.Commit(ctx, txn)
+}
+
+// removeMembership は subject の resourceID に対するロールを削除する。存在しない場合は何もしない
+func removeMembership(ctx context.Context, resourceType, resourceID, subject string) error {
+	err := storage.WriteOne(ctx, store, storage.RemoveOp, storage.Path{"memberships", resourceType, subject, resourceID}, nil)
+	if storage.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// メンバーシップ一覧を取得するハンドラ
+func getMembershipsHandler(w http.ResponseWriter, r *http.Request) {
+	memberships, err := storage.ReadOne(r.Context(), store, storage.Path{"memberships"})
+	if err != nil {
+		http.Error(w, "Failed to read memberships: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"memberships": memberships,
+	})
+}
+
+// メンバーのロールを設定するハンドラ
+func putMembershipHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	roles, ok := membershipRoles[vars["type"]]
+	if !ok {
+		http.Error(w, "Unknown resource type", http.StatusBadRequest)
+		return
+	}
+
+	var req MembershipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !roles[req.Role] {
+		http.Error(w, "Unknown role for "+vars["type"]+": "+req.Role, http.StatusBadRequest)
+		return
+	}
+
+	if err := setMembership(r.Context(), vars["type"], vars["resourceId"], vars["subject"], req.Role); err != nil {
+		http.Error(w, "Failed to set membership: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("Membership set: %s %s:%s -> %s\n", vars["subject"], vars["type"], vars["resourceId"], req.Role)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"type":     vars["type"],
+		"resource": vars["resourceId"],
+		"subject":  vars["subject"],
+		"role":     req.Role,
+	})
+}
+
+// メンバーのロールを削除するハンドラ
+func deleteMembershipHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if _, ok := membershipRoles[vars["type"]]; !ok {
+		http.Error(w, "Unknown resource type", http.StatusBadRequest)
+		return
+	}
+
+	if err := removeMembership(r.Context(), vars["type"], vars["resourceId"], vars["subject"]); err != nil {
+		http.Error(w, "Failed to remove membership: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("Membership removed: %s %s:%s\n", vars["subject"], vars["type"], vars["resourceId"])
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"type":     vars["type"],
+		"resource": vars["resourceId"],
+		"subject":  vars["subject"],
+	})
+}
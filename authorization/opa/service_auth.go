@@ -0,0 +1,117 @@
+package main
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// サービス間認証の設定。preshared key と mTLS クライアント証明書のどちらか（または両方）を使う
+type serviceAuthConfig struct {
+	presharedKey string
+	certFile     string
+	keyFile      string
+	clientCAFile string
+}
+
+var serviceAuth serviceAuthConfig
+
+// CORSで許可するオリジン（フロントエンドの開発サーバ）
+var allowedOrigins = func() []string {
+	if origins := os.Getenv("CORS_ALLOWED_ORIGINS"); origins != "" {
+		return strings.Split(origins, ",")
+	}
+	return []string{"http://localhost:3000", "http://localhost:3001"}
+}()
+
+// loadServiceAuthConfig は環境変数からサービス間認証の設定を読み込む
+func loadServiceAuthConfig() (serviceAuthConfig, error) {
+	cfg := serviceAuthConfig{
+		presharedKey: os.Getenv("OPA_PRESHARED_KEY"),
+		certFile:     os.Getenv("OPA_TLS_CERT_FILE"),
+		keyFile:      os.Getenv("OPA_TLS_KEY_FILE"),
+		clientCAFile: os.Getenv("OPA_TLS_CLIENT_CA_FILE"),
+	}
+
+	if cfg.clientCAFile != "" && cfg.certFile == "" {
+		return cfg, fmt.Errorf("OPA_TLS_CLIENT_CA_FILE requires OPA_TLS_CERT_FILE and OPA_TLS_KEY_FILE")
+	}
+	if cfg.presharedKey == "" && cfg.clientCAFile == "" {
+		return cfg, fmt.Errorf("OPA_PRESHARED_KEY or OPA_TLS_CLIENT_CA_FILE is required")
+	}
+	return cfg, nil
+}
+
+// requireServiceAuth は preshared key または検証済みのクライアント証明書を持つリクエストのみ通す
+func requireServiceAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if serviceAuth.authenticated(r) {
+			next(w, r)
+			return
+		}
+
+		log.Printf("Service authentication failed: %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "service authentication required",
+		})
+	}
+}
+
+func (cfg serviceAuthConfig) authenticated(r *http.Request) bool {
+	// mTLS: ClientCAs で検証済みの証明書があれば認証済みとする
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		return true
+	}
+
+	if cfg.presharedKey == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(cfg.presharedKey)) == 1
+}
+
+// listenAndServe は TLS 証明書が設定されていれば HTTPS（クライアント証明書の検証付き）で、なければ HTTP で待ち受ける
+func (cfg serviceAuthConfig) listenAndServe(addr string, handler http.Handler) error {
+	if cfg.certFile == "" {
+		return http.ListenAndServe(addr, handler)
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.clientCAFile != "" {
+		caPEM, err := os.ReadFile(cfg.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return fmt.Errorf("no certificates found in %s", cfg.clientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		// preshared key のクライアントも受け付けるため、証明書は提示された場合のみ検証する
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	server := &http.Server{Addr: addr, Handler: handler, TLSConfig: tlsConfig}
+	return server.ListenAndServeTLS(cfg.certFile, cfg.keyFile)
+}
+
+func isAllowedOrigin(origin string) bool {
+	for _, allowed := range allowedOrigins {
+		if strings.TrimSpace(allowed) == origin {
+			return true
+		}
+	}
+	return false
+}
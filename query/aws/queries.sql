@@ -4,6 +4,9 @@ SELECT * FROM aws_account WHERE id = $1;
 -- name: GetAwsAccounts :many
 SELECT * FROM aws_account;
 
+-- name: GetAwsAccountsByIDs :many
+SELECT * FROM aws_account WHERE id = ANY(@ids::text[]);
+
 -- name: GetAwsAccountBySystemId :many
 SELECT * FROM aws_account t1 left join aws_account_system_relation t2 on t1.id = t2.aws_account_id where t2.system_id = $1;
 
@@ -11,4 +14,12 @@ SELECT * FROM aws_account t1 left join aws_account_system_relation t2 on t1.id =
 SELECT * FROM aws_account t1 left join aws_account_user_relation t2 on t1.id = t2.aws_account_id where t2.aws_account_id = $1;
 
 -- name: UpdateAwsAccount :one
-UPDATE aws_account SET name = $2, note = $3 WHERE id = $1 RETURNING *;
\ No newline at end of file
+UPDATE aws_account SET name = $2, note = $3 WHERE id = $1 RETURNING *;
+
+-- name: AddAwsAccountMember :one
+INSERT INTO aws_account_user_relation (id, aws_account_id, user_id, role)
+VALUES (gen_random_uuid()::text, $1, $2, $3)
+RETURNING *;
+
+-- name: ListAwsAccountMembers :many
+SELECT * FROM aws_account_user_relation ORDER BY aws_account_id, user_id;
@@ -0,0 +1,34 @@
+-- 認可エンジンへ未反映のメンバー変更（リレーションと同じトランザクションで書き込む）
+CREATE TABLE authz_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    resource_type TEXT NOT NULL,
+    resource_id TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    old_role TEXT NOT NULL DEFAULT '',
+    new_role TEXT NOT NULL DEFAULT '',
+    delivered_engines TEXT[] NOT NULL DEFAULT '{}',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX authz_outbox_next_attempt_at_idx ON authz_outbox (next_attempt_at);
+
+-- 再試行の上限に達したメンバー変更
+CREATE TABLE authz_outbox_dead_letter (
+    id BIGINT PRIMARY KEY,
+    resource_type TEXT NOT NULL,
+    resource_id TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    old_role TEXT NOT NULL,
+    new_role TEXT NOT NULL,
+    delivered_engines TEXT[] NOT NULL,
+    attempts INT NOT NULL,
+    last_error TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    failed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- dead-letter が残っているメンバーの後続イベントは、運用者が解消する（行を削除する）まで反映しない
+CREATE INDEX authz_outbox_dead_letter_member_idx ON authz_outbox_dead_letter (resource_type, resource_id, subject);
@@ -0,0 +1,48 @@
+-- name: EnqueueOutboxEvent :exec
+INSERT INTO authz_outbox (resource_type, resource_id, subject, old_role, new_role)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: ClaimOutboxEvents :many
+UPDATE authz_outbox
+SET next_attempt_at = now() + make_interval(secs => @lease_seconds::float8)
+WHERE id IN (
+    SELECT o.id FROM authz_outbox o
+    WHERE o.next_attempt_at <= now()
+      AND NOT EXISTS (
+          SELECT 1 FROM authz_outbox p
+          WHERE p.resource_type = o.resource_type AND p.resource_id = o.resource_id AND p.subject = o.subject AND p.id < o.id
+      )
+      AND NOT EXISTS (
+          SELECT 1 FROM authz_outbox_dead_letter d
+          WHERE d.resource_type = o.resource_type AND d.resource_id = o.resource_id AND d.subject = o.subject
+      )
+    ORDER BY o.id
+    LIMIT @batch_size::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: RecordOutboxDelivery :exec
+UPDATE authz_outbox SET delivered_engines = $2 WHERE id = $1;
+
+-- name: CompleteOutboxEvent :exec
+DELETE FROM authz_outbox WHERE id = $1;
+
+-- name: RetryOutboxEvent :exec
+UPDATE authz_outbox
+SET attempts = @attempts, last_error = @last_error, next_attempt_at = now() + make_interval(secs => @delay_seconds::float8)
+WHERE id = @id;
+
+-- name: DeadLetterOutboxEvent :exec
+WITH moved AS (
+    DELETE FROM authz_outbox WHERE id = @id RETURNING *
+)
+INSERT INTO authz_outbox_dead_letter (id, resource_type, resource_id, subject, old_role, new_role, delivered_engines, attempts, last_error, created_at)
+SELECT id, resource_type, resource_id, subject, old_role, new_role, delivered_engines, @attempts::int, @last_error::text, created_at FROM moved;
+
+-- name: GetOutboxLag :one
+SELECT
+    count(*) AS pending,
+    COALESCE(EXTRACT(EPOCH FROM now() - min(created_at)), 0)::float8 AS oldest_seconds,
+    (SELECT count(*) FROM authz_outbox_dead_letter) AS dead_letters
+FROM authz_outbox;
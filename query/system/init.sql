@@ -7,7 +7,9 @@ CREATE TABLE system (
 CREATE TABLE system_user_relation (
     id TEXT PRIMARY KEY,
     system_id TEXT NOT NULL,
-    user_id TEXT NOT NULL
+    user_id TEXT NOT NULL,
+    role TEXT NOT NULL DEFAULT 'staff' CHECK (role IN ('owner', 'manager', 'staff')),
+    UNIQUE (system_id, user_id)
 );
 
 -- システム作成
@@ -17,17 +19,17 @@ INSERT INTO system (id, name, note) VALUES ('system3', 'System 3', 'Production S
 INSERT INTO system (id, name, note) VALUES ('system4', 'System 4', 'Testing System');
 
 -- システム権限割り当て
--- jiro: system1とsystem2に所属
-INSERT INTO system_user_relation (id, system_id, user_id) VALUES ('0001', 'system1', 'jiro');
-INSERT INTO system_user_relation (id, system_id, user_id) VALUES ('0002', 'system2', 'jiro');
+-- jiro: system1とsystem2のオーナー
+INSERT INTO system_user_relation (id, system_id, user_id, role) VALUES ('0001', 'system1', 'jiro', 'owner');
+INSERT INTO system_user_relation (id, system_id, user_id, role) VALUES ('0002', 'system2', 'jiro', 'owner');
 
--- saburo: system1とsystem3に所属
-INSERT INTO system_user_relation (id, system_id, user_id) VALUES ('0003', 'system1', 'saburo');
-INSERT INTO system_user_relation (id, system_id, user_id) VALUES ('0004', 'system3', 'saburo');
+-- saburo: system1とsystem3のマネージャー
+INSERT INTO system_user_relation (id, system_id, user_id, role) VALUES ('0003', 'system1', 'saburo', 'manager');
+INSERT INTO system_user_relation (id, system_id, user_id, role) VALUES ('0004', 'system3', 'saburo', 'manager');
 
--- hanako: system2とsystem3に所属
-INSERT INTO system_user_relation (id, system_id, user_id) VALUES ('0005', 'system2', 'hanako');
-INSERT INTO system_user_relation (id, system_id, user_id) VALUES ('0006', 'system3', 'hanako');
+-- hanako: system2とsystem3のスタッフ
+INSERT INTO system_user_relation (id, system_id, user_id, role) VALUES ('0005', 'system2', 'hanako', 'staff');
+INSERT INTO system_user_relation (id, system_id, user_id, role) VALUES ('0006', 'system3', 'hanako', 'staff');
 
--- alice: system4に所属
-INSERT INTO system_user_relation (id, system_id, user_id) VALUES ('0007', 'system4', 'alice');
\ No newline at end of file
+-- alice: system4のスタッフ
+INSERT INTO system_user_relation (id, system_id, user_id, role) VALUES ('0007', 'system4', 'alice', 'staff');
@@ -4,8 +4,11 @@ SELECT * FROM system WHERE id = $1;
 -- name: GetSystems :many
 SELECT * FROM system;
 
+-- name: GetSystemsByIDs :many
+SELECT * FROM system WHERE id = ANY(@ids::text[]);
+
 -- name: GetSystemAccounts :many
-SELECT t1.id, t1.name, t1.note, t2.id, t2.system_id, t2.user_id 
+SELECT t1.id, t1.name, t1.note, t2.id, t2.system_id, t2.user_id, t2.role 
 FROM system t1 
 LEFT JOIN system_user_relation t2 ON t1.id = t2.system_id 
 WHERE t1.id = $1;
@@ -15,3 +18,23 @@ UPDATE system
 SET name = $2, note = $3 
 WHERE id = $1 
 RETURNING id, name, note;
+
+-- name: GetSystemMemberForUpdate :one
+SELECT * FROM system_user_relation WHERE system_id = $1 AND user_id = $2 FOR UPDATE;
+
+-- name: AddSystemMember :one
+INSERT INTO system_user_relation (id, system_id, user_id, role)
+VALUES (gen_random_uuid()::text, $1, $2, $3)
+RETURNING *;
+
+-- name: UpdateSystemMemberRole :one
+UPDATE system_user_relation
+SET role = $3
+WHERE system_id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteSystemMember :execrows
+DELETE FROM system_user_relation WHERE system_id = $1 AND user_id = $2;
+
+-- name: ListSystemMembers :many
+SELECT * FROM system_user_relation ORDER BY system_id, user_id;